@@ -1,47 +1,65 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"lang"
+	"lang/internal/check"
 	"lang/internal/eval"
 	"lang/internal/lexer"
 	"lang/internal/parser"
 	"lang/internal/repl"
 
 	"os"
+	"os/signal"
 )
 
-func evalit(source string) (error) {
-	lexer := lexer.NewLexer();
-	toks, err := lexer.Read(source);
-	//fmt.Println("Scanning done")
-	parser := parser.NewParser(toks);
-	if err != nil {
-		return err
-	}
+func evalit(source string) {
+	// Ctrl-C stops the program, a second one kills the process
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	context.AfterFunc(ctx, stop)
+	defer stop()
 
-	if len(toks) == 0 {
-		fmt.Println("No tokens found");
-		return nil
+	err := lang.NewVM(lang.Options{}).RunStringContext(ctx, source)
+	var script *lang.Error
+	if !errors.As(err, &script) {
+		return
 	}
-
-	mnode, errs := parser.Parse()
-
-	if len(errs) != 0 {
-		for _, err := range errs {
-			fmt.Println(err)
-		}
-	} else {
-		// mnode.Print()
-		evaluator := eval.NewEvaluatorAutoEnv(mnode)
-		evaluator.Eval()
-		if len(evaluator.Errors) > 0 {
-			for _, err := range evaluator.Errors {
-				fmt.Println("Runtime error: ", err)
-			}
+	interrupted := false
+	for _, err := range script.Errors {
+		switch {
+		case script.Syntax:
+			fmt.Fprintln(os.Stderr, err)
+		case errors.Is(err, lang.ErrCancelled):
+			interrupted = true
+		default:
+			fmt.Fprintln(os.Stderr, "Runtime error: ", err)
 		}
 	}
+	if interrupted {
+		fmt.Fprintln(os.Stderr, "Interrupted")
+		os.Exit(130)
+	}
+}
 
-	return nil
+// checkit reports the scope and type errors of the file without
+// running it.
+func checkit(source string) bool {
+	toks, err := lexer.NewLexer().Read(source)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+	mnode, errs := parser.NewParser(toks).Parse()
+	if len(errs) == 0 {
+		errs = eval.NewEvaluatorAutoEnv(mnode).Resolve()
+		errs = append(errs, check.Check(mnode)...)
+	}
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	return len(errs) == 0
 }
 
 func enterRepl() {
@@ -57,11 +75,24 @@ func main() {
 	} else {
 		if args[1] == "repl" {
 			enterRepl()
+		} else if args[1] == "check" {
+			if len(args) < 3 {
+				fmt.Fprintln(os.Stderr, "Usage: lang check <file>")
+				os.Exit(2)
+			}
+			data, err := os.ReadFile(args[2])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Failed to read file: ", err)
+				os.Exit(2)
+			}
+			if !checkit(string(data)) {
+				os.Exit(1)
+			}
 		} else {
 			fileName := args[1]
 			data, err := os.ReadFile(fileName)
 			if err != nil {
-				fmt.Println("Failed to read file: ", err)
+				fmt.Fprintln(os.Stderr, "Failed to read file: ", err)
 				return
 			}
 			content := string(data)
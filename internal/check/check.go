@@ -0,0 +1,431 @@
+package check
+
+import (
+	"fmt"
+	"lang/internal/parser"
+)
+
+// Checker walks a parsed program before it runs and reports values that
+// don't match a type annotation. Only annotated declarations are checked,
+// everything else is left to the evaluator.
+type Checker struct {
+	Errors  []error
+	funcs   map[string]*signature
+	classes map[string]*class
+	// names brought in by 'import file > a, b', not checked further
+	imported map[string]bool
+	scopes   []map[string]string
+	// declared return types of the enclosing functions
+	returns []string
+}
+
+type signature struct {
+	params  []string
+	types   []string
+	returns string
+	// parameters without a default value, not counting a variadic one
+	required int
+	variadic bool
+}
+
+func newSignature(params, types []string, defaults []parser.Node, variadic bool, returns string) *signature {
+	sig := &signature{params: params, types: types, returns: returns, variadic: variadic}
+	for i := range params {
+		if (i >= len(defaults) || defaults[i] == nil) && !(variadic && i == len(params)-1) {
+			sig.required++
+		}
+	}
+	return sig
+}
+
+type class struct {
+	fields  map[string]string
+	static  map[string]bool
+	methods map[string]*signature
+}
+
+func NewChecker() *Checker {
+	return &Checker{
+		funcs:    make(map[string]*signature),
+		classes:  make(map[string]*class),
+		imported: make(map[string]bool),
+		scopes:   []map[string]string{make(map[string]string)},
+	}
+}
+
+// Check type checks program and returns every mismatch it found.
+func Check(program *parser.ProgramNode) []error {
+	c := NewChecker()
+	c.Check(program)
+	return c.Errors
+}
+
+func (c *Checker) Check(program *parser.ProgramNode) {
+	c.declare(program.Nodes)
+	for _, node := range program.Nodes {
+		c.stmt(node)
+	}
+}
+
+func (c *Checker) errorf(node parser.Node, format string, args ...any) {
+	pos := parser.Position{Row: -1, Column: -1}
+	if n, ok := node.(interface{ Pos() parser.Position }); ok {
+		pos = n.Pos()
+	}
+	c.Errors = append(c.Errors, fmt.Errorf("Type error in %d:%d: %s",
+		pos.Row, pos.Column, fmt.Sprintf(format, args...)))
+}
+
+// declare collects classes, enums, functions and methods up front, so
+// they can be used before the line declaring them.
+func (c *Checker) declare(nodes []parser.Node) {
+	for _, node := range nodes {
+		switch def := node.(type) {
+		case *parser.ImportNode:
+			for _, name := range def.Symbols {
+				c.imported[name] = true
+			}
+		case *parser.StructDefNode:
+			cl := &class{
+				fields:  make(map[string]string),
+				static:  make(map[string]bool),
+				methods: make(map[string]*signature),
+			}
+			for _, field := range def.Fields {
+				cl.fields[field.Name] = field.Type
+				cl.static[field.Name] = field.IsStatic
+			}
+			c.classes[def.Name] = cl
+		case *parser.EnumDefNode:
+			cl := &class{
+				fields:  make(map[string]string),
+				static:  make(map[string]bool),
+				methods: enumMethods(def.Name),
+			}
+			for _, member := range def.Members {
+				cl.fields[member] = def.Name
+				cl.static[member] = true
+			}
+			cl.fields["name"] = "string"
+			cl.fields["ordinal"] = "int"
+			for _, name := range []string{"values", "fromString", "fromInt"} {
+				cl.static[name] = true
+			}
+			c.classes[def.Name] = cl
+		}
+	}
+
+	for _, node := range nodes {
+		switch def := node.(type) {
+		case *parser.FunctionDefNode:
+			c.funcs[def.Name] = newSignature(def.Parameters, def.ParamTypes,
+				def.Defaults, def.Variadic, returnType(def.ReturnType, def.IsGenerator))
+		case *parser.StructMethodDef:
+			cl, ok := c.classes[def.StructName]
+			if !ok {
+				continue
+			}
+			cl.methods[def.MethodName] = newSignature(def.Parameters, def.ParamTypes,
+				def.Defaults, def.Variadic, returnType(def.ReturnType, def.IsGenerator))
+			cl.static[def.MethodName] = def.IsStatic
+		}
+	}
+}
+
+// returnType is the type calls to a function evaluate to.
+func returnType(annotated string, isGenerator bool) string {
+	if isGenerator {
+		return "generator"
+	}
+	return annotated
+}
+
+func enumMethods(name string) map[string]*signature {
+	return map[string]*signature{
+		"name":       {returns: "string"},
+		"toString":   {returns: "string"},
+		"ordinal":    {returns: "int"},
+		"toInt":      {returns: "int"},
+		"values":     {returns: "[]" + name},
+		"fromString": {params: []string{"s"}, types: []string{"string"}, returns: name, required: 1},
+		"fromInt":    {params: []string{"i"}, types: []string{"int"}, returns: name, required: 1},
+	}
+}
+
+func (c *Checker) pushScope() {
+	c.scopes = append(c.scopes, make(map[string]string))
+}
+
+func (c *Checker) popScope() {
+	c.scopes = c.scopes[:len(c.scopes)-1]
+}
+
+func (c *Checker) define(name, t string) {
+	c.scopes[len(c.scopes)-1][name] = t
+}
+
+// lookup returns the declared type of a variable and whether it exists.
+func (c *Checker) lookup(name string) (string, bool) {
+	for i := len(c.scopes) - 1; i >= 0; i-- {
+		if t, ok := c.scopes[i][name]; ok {
+			return t, true
+		}
+	}
+	return "", false
+}
+
+// validType reports annotations naming a type that doesn't exist.
+func (c *Checker) validType(node parser.Node, t string) bool {
+	base := t
+	for isArrayType(base) {
+		base = elemType(base)
+	}
+	if base == "" || builtinTypes[base] {
+		return true
+	}
+	if _, ok := c.classes[base]; ok || c.imported[base] {
+		return true
+	}
+	c.errorf(node, "unknown type '%s'", t)
+	return false
+}
+
+func (c *Checker) stmt(node parser.Node) {
+	switch n := node.(type) {
+	case *parser.VarDefNode:
+		c.varDef(n)
+	case *parser.FunctionDefNode:
+		sig := newSignature(n.Parameters, n.ParamTypes,
+			n.Defaults, n.Variadic, returnType(n.ReturnType, n.IsGenerator))
+		c.function(n, sig, n.Defaults, "", n.Body)
+	case *parser.StructMethodDef:
+		self := ""
+		if !n.IsStatic {
+			self = n.StructName
+		}
+		sig := newSignature(n.Parameters, n.ParamTypes,
+			n.Defaults, n.Variadic, returnType(n.ReturnType, n.IsGenerator))
+		c.function(n, sig, n.Defaults, self, n.Body)
+	case *parser.StructDefNode:
+		for _, field := range n.Fields {
+			if !c.validType(field, field.Type) || field.Value == nil {
+				continue
+			}
+			if t := c.expr(field.Value); !Assignable(field.Type, t) {
+				c.errorf(field, "cannot use %s as %s in field '%s' of class '%s'",
+					t, field.Type, field.Name, n.Name)
+			}
+		}
+	case *parser.AssignmentNode:
+		c.assignment(n)
+	case *parser.ReturnNode:
+		c.ret(n)
+	case *parser.YieldNode:
+		if n.Value != nil {
+			c.expr(n.Value)
+		}
+	case *parser.BlockNode:
+		c.block(n)
+	case *parser.IfNode:
+		c.condition(n.Condition)
+		c.block(n.ThenBranch)
+		c.block(n.ElseBranch)
+	case *parser.WhileNode:
+		if n.Condition != nil {
+			c.condition(n.Condition)
+		}
+		c.block(n.Body)
+	case *parser.DoWhileNode:
+		c.block(n.Body)
+		c.condition(n.Condition)
+	case *parser.ForNode:
+		c.pushScope()
+		if n.Init != nil {
+			c.stmt(n.Init)
+		}
+		if n.Condition != nil {
+			c.condition(n.Condition)
+		}
+		if n.Post != nil {
+			c.stmt(n.Post)
+		}
+		c.block(n.Body)
+		c.popScope()
+	case *parser.ForInNode:
+		item := c.itemType(c.expr(n.Iterable))
+		c.pushScope()
+		if n.Pattern != nil {
+			c.destructure(n.Pattern, item)
+		} else {
+			c.define(n.Var, item)
+		}
+		c.block(n.Body)
+		c.popScope()
+	case *parser.ExpressionStatementNode:
+		c.expr(n.Expr)
+	case *parser.EnumDefNode, *parser.ImportNode, *parser.BreakNode,
+		*parser.ContinueNode, *parser.SemicolonNode:
+	default:
+		c.expr(node)
+	}
+}
+
+func (c *Checker) block(block *parser.BlockNode) {
+	if block == nil {
+		return
+	}
+	c.pushScope()
+	for _, stmt := range block.Statements {
+		c.stmt(stmt)
+	}
+	c.popScope()
+}
+
+func (c *Checker) condition(cond parser.Node) {
+	if t := c.expr(cond); !Assignable("bool", t) {
+		c.errorf(cond, "condition must be bool, got %s", t)
+	}
+}
+
+func (c *Checker) varDef(n *parser.VarDefNode) {
+	if n.Pattern != nil {
+		c.destructure(n.Pattern, c.expr(n.Value))
+		return
+	}
+	if !c.validType(n, n.Type) {
+		c.define(n.Name, "")
+		return
+	}
+	if n.Value != nil {
+		if t := c.expr(n.Value); !Assignable(n.Type, t) {
+			c.errorf(n, "cannot use %s as %s in definition of '%s'",
+				t, n.Type, n.Name)
+		}
+	}
+	// unannotated variables may later hold anything
+	c.define(n.Name, n.Type)
+}
+
+func (c *Checker) function(
+	node parser.Node,
+	sig *signature,
+	defaults []parser.Node,
+	self string,
+	body *parser.BlockNode) {
+
+	c.validType(node, sig.returns)
+	c.pushScope()
+	if self != "" {
+		c.define("self", self)
+	}
+	for i, name := range sig.params {
+		t := ""
+		if i < len(sig.types) && c.validType(node, sig.types[i]) {
+			t = sig.types[i]
+		}
+		if i < len(defaults) && defaults[i] != nil {
+			if dt := c.expr(defaults[i]); !Assignable(t, dt) {
+				c.errorf(defaults[i], "cannot use %s as %s in default of '%s'",
+					dt, t, name)
+			}
+		}
+		if sig.variadic && i == len(sig.params)-1 {
+			t = "[]" + t
+		}
+		c.define(name, t)
+	}
+	if sig.returns == "generator" {
+		// 'return;' only ends a generator
+		c.returns = append(c.returns, "")
+	} else {
+		c.returns = append(c.returns, sig.returns)
+	}
+	c.block(body)
+	c.returns = c.returns[:len(c.returns)-1]
+	c.popScope()
+}
+
+func (c *Checker) ret(n *parser.ReturnNode) {
+	if len(c.returns) == 0 {
+		if n.Value != nil {
+			c.expr(n.Value)
+		}
+		return
+	}
+	want := c.returns[len(c.returns)-1]
+	if n.Value == nil {
+		if !Assignable(want, "nil") {
+			c.errorf(n, "missing return value, expected %s", want)
+		}
+		return
+	}
+	if t := c.expr(n.Value); !Assignable(want, t) {
+		c.errorf(n, "cannot return %s from function returning %s", t, want)
+	}
+}
+
+func (c *Checker) assignment(n *parser.AssignmentNode) {
+	target := c.expr(n.Name)
+	value := c.expr(n.Value)
+	if n.Op == "+=" || n.Op == "-=" {
+		value = c.arithmetic(n, n.Op[:1], target, value)
+	}
+	if !Assignable(target, value) {
+		c.errorf(n, "cannot assign %s to '%s' of type %s",
+			value, describe(n.Name), target)
+	}
+}
+
+// destructure defines the names of a pattern unpacking a value of type t.
+func (c *Checker) destructure(pat *parser.Destructure, t string) {
+	types := make([]string, len(pat.Names))
+	if pat.ByField() {
+		if cl, ok := c.classes[t]; ok {
+			for i, field := range pat.Fields {
+				ft, exists := cl.fields[field]
+				if !exists {
+					c.errorf(pat, "class '%s' has no field '%s'", t, field)
+				}
+				types[i] = ft
+			}
+		}
+	} else if isArrayType(t) {
+		for i := range types {
+			types[i] = elemType(t)
+		}
+	}
+	for i, name := range pat.Names {
+		if name != "_" {
+			c.define(name, types[i])
+		}
+	}
+	if pat.Rest != "" {
+		c.define(pat.Rest, "[]"+c.itemType(t))
+	}
+}
+
+// itemType is the type of the loop variable when iterating over t.
+func (c *Checker) itemType(t string) string {
+	switch {
+	case t == "range":
+		return "int"
+	case t == "string":
+		return "string"
+	case isArrayType(t):
+		return elemType(t)
+	}
+	return ""
+}
+
+// describe names an assignment target in error messages.
+func describe(target parser.Node) string {
+	switch t := target.(type) {
+	case *parser.IdentifierNode:
+		return t.Name
+	case *parser.StructMethodCall:
+		return describe(t.Caller) + "." + t.MethodName
+	case *parser.ArrayAccessNode:
+		return describe(t.Target) + "[...]"
+	}
+	return target.String()
+}
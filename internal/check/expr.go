@@ -0,0 +1,366 @@
+package check
+
+import (
+	"lang/internal/parser"
+	"slices"
+)
+
+// Result types of builtins that always return the same type.
+var builtinReturns = map[string]string{
+	"int":         "int",
+	"float":       "float",
+	"string":      "string",
+	"len":         "int",
+	"type":        "string",
+	"input":       "string",
+	"ord":         "int",
+	"range":       "range",
+	"array":       "[]",
+	"matrix":      "[]",
+	"channel":     "channel",
+	"setTimeout":  "int",
+	"setInterval": "int",
+	"clearTimer":  "bool",
+}
+
+// expr checks an expression and returns its static type, "" when it
+// can't be known before running.
+func (c *Checker) expr(node parser.Node) string {
+	switch n := node.(type) {
+	case *parser.LiteralNode:
+		switch n.Value.(type) {
+		case int:
+			return "int"
+		case float64:
+			return "float"
+		case string:
+			return "string"
+		case bool:
+			return "bool"
+		}
+	case *parser.TrueNode, *parser.FalseNode:
+		return "bool"
+	case *parser.NilNode:
+		return "nil"
+	case *parser.IdentifierNode:
+		t, _ := c.lookup(n.Name)
+		return t
+	case *parser.ArrayNode:
+		return c.array(n)
+	case *parser.ArrayAccessNode:
+		t := c.expr(n.Target)
+		c.expr(n.Index)
+		if isArrayType(t) {
+			return elemType(t)
+		}
+		if t == "string" {
+			return "string"
+		}
+	case *parser.RangeNode:
+		for _, bound := range []parser.Node{n.Start, n.End} {
+			if t := c.expr(bound); !Assignable("int", t) || t == "float" {
+				c.errorf(n, "range bounds must be int, got %s", t)
+			}
+		}
+		return "range"
+	case *parser.BinaryOpNode:
+		return c.binary(n)
+	case *parser.UnaryOpNode:
+		t := c.expr(n.Expr)
+		switch n.Op {
+		case "!":
+			return "bool"
+		case "-", "++", "--":
+			if isNumeric(t) {
+				return t
+			}
+		}
+	case *parser.FunctionCallNode:
+		return c.call(n)
+	case *parser.StructInitNode:
+		return c.structInit(n)
+	case *parser.StructMethodCall:
+		return c.member(n)
+	case *parser.MatchNode:
+		return c.match(n)
+	case *parser.SpawnNode:
+		c.expr(n.Call)
+		return "task"
+	case *parser.AssignmentNode:
+		c.assignment(n)
+	}
+	return ""
+}
+
+func (c *Checker) array(n *parser.ArrayNode) string {
+	elem := ""
+	for i, el := range n.Elements {
+		t := c.expr(el)
+		switch {
+		case i == 0:
+			elem = t
+		case elem == "int" && t == "float":
+			elem = "float"
+		case elem == "float" && t == "int":
+		case t != elem:
+			elem = ""
+		}
+	}
+	return "[]" + elem
+}
+
+func (c *Checker) binary(n *parser.BinaryOpNode) string {
+	left := c.expr(n.Left)
+	right := c.expr(n.Right)
+	switch n.Op {
+	case "&&", "||":
+		for _, t := range []string{left, right} {
+			if !Assignable("bool", t) {
+				c.errorf(n, "operator '%s' needs bool operands, got %s", n.Op, t)
+			}
+		}
+		return "bool"
+	case "==", "!=":
+		return "bool"
+	case "<", ">", "<=", ">=":
+		if c.isClass(left) {
+			// overloaded through 'lt'
+			return ""
+		}
+		return "bool"
+	}
+	return c.arithmetic(n, n.Op, left, right)
+}
+
+// arithmetic returns the type of 'left op right' for + - * /.
+func (c *Checker) arithmetic(node parser.Node, op, left, right string) string {
+	if left == "" || right == "" || c.isClass(left) {
+		return ""
+	}
+	if isNumeric(left) && isNumeric(right) {
+		if left == "int" && right == "int" {
+			return "int"
+		}
+		return "float"
+	}
+	if op == "+" && left == "string" && right == "string" {
+		return "string"
+	}
+	c.errorf(node, "operator '%s' is not defined for %s and %s", op, left, right)
+	return ""
+}
+
+func (c *Checker) isClass(t string) bool {
+	_, ok := c.classes[t]
+	return ok
+}
+
+// checkArgs reports calls to an annotated function or method with the
+// wrong number or types of arguments.
+func (c *Checker) checkArgs(node parser.Node, name string, sig *signature, args []parser.Node) {
+	types := make([]string, len(args))
+	for i, arg := range args {
+		if named, ok := arg.(*parser.NamedArgNode); ok {
+			arg = named.Value
+		}
+		types[i] = c.expr(arg)
+	}
+	if sig == nil {
+		return
+	}
+
+	fixed := len(sig.params)
+	if sig.variadic {
+		fixed--
+	}
+	bound := make([]bool, len(sig.params))
+	positional := 0
+	for i, arg := range args {
+		param := -1
+		if named, ok := arg.(*parser.NamedArgNode); ok {
+			param = slices.Index(sig.params[:fixed], named.Name)
+			if param < 0 {
+				c.errorf(arg, "'%s' has no parameter '%s'", name, named.Name)
+				continue
+			}
+			if bound[param] {
+				c.errorf(arg, "argument '%s' of '%s' is passed more than once",
+					named.Name, name)
+				continue
+			}
+		} else {
+			if positional >= fixed && !sig.variadic {
+				c.errorf(node, "'%s' expects at most %d arguments, got %d",
+					name, fixed, len(args))
+				return
+			}
+			param = min(positional, len(sig.params)-1)
+			positional++
+		}
+		bound[param] = true
+		if param < len(sig.types) && !Assignable(sig.types[param], types[i]) {
+			c.errorf(arg, "cannot use %s as %s in argument '%s' of '%s'",
+				types[i], sig.types[param], sig.params[param], name)
+		}
+	}
+
+	if missing := slices.Index(bound[:sig.required], false); missing >= 0 {
+		if len(args) < sig.required && !slices.ContainsFunc(args, isNamedArg) {
+			c.errorf(node, "'%s' expects %d arguments, got %d",
+				name, sig.required, len(args))
+			return
+		}
+		c.errorf(node, "missing argument '%s' in call to '%s'",
+			sig.params[missing], name)
+	}
+}
+
+func isNamedArg(arg parser.Node) bool {
+	_, ok := arg.(*parser.NamedArgNode)
+	return ok
+}
+
+func (c *Checker) call(n *parser.FunctionCallNode) string {
+	ident, ok := n.Name.(*parser.IdentifierNode)
+	if !ok {
+		c.expr(n.Name)
+		c.checkArgs(n, "", nil, n.Args)
+		return ""
+	}
+	if _, isVar := c.lookup(ident.Name); isVar {
+		c.checkArgs(n, ident.Name, nil, n.Args)
+		return ""
+	}
+	if sig, ok := c.funcs[ident.Name]; ok {
+		c.checkArgs(n, ident.Name, sig, n.Args)
+		return sig.returns
+	}
+	if _, ok := c.classes[ident.Name]; ok {
+		return c.construct(n, ident.Name, n.Args)
+	}
+	c.checkArgs(n, ident.Name, nil, n.Args)
+	return builtinReturns[ident.Name]
+}
+
+// construct checks 'Name(args)' against the class 'init' method.
+func (c *Checker) construct(node parser.Node, name string, args []parser.Node) string {
+	sig := c.classes[name].methods["init"]
+	c.checkArgs(node, name+"->init", sig, args)
+	return name
+}
+
+func (c *Checker) structInit(n *parser.StructInitNode) string {
+	cl, ok := c.classes[n.Name]
+	if !ok {
+		return ""
+	}
+	if n.IsCall {
+		return c.construct(n, n.Name, n.Args)
+	}
+	for _, init := range n.InitFields {
+		assign, ok := init.(*parser.AssignmentNode)
+		if !ok {
+			continue
+		}
+		field, ok := assign.Name.(*parser.IdentifierNode)
+		if !ok {
+			continue
+		}
+		t := c.expr(assign.Value)
+		want, exists := cl.fields[field.Name]
+		if !exists || cl.static[field.Name] {
+			c.errorf(n, "class '%s' has no field '%s'", n.Name, field.Name)
+			continue
+		}
+		if !Assignable(want, t) {
+			c.errorf(n, "cannot use %s as %s in field '%s' of class '%s'",
+				t, want, field.Name, n.Name)
+		}
+	}
+	return n.Name
+}
+
+// member checks 'x.field', 'x.method(...)' and their static forms
+// 'Class.field', 'Class.method(...)'.
+func (c *Checker) member(n *parser.StructMethodCall) string {
+	className := ""
+	static := false
+	if ident, ok := n.Caller.(*parser.IdentifierNode); ok {
+		if _, isVar := c.lookup(ident.Name); !isVar && c.isClass(ident.Name) {
+			className, static = ident.Name, true
+		}
+	}
+	if className == "" {
+		className = c.expr(n.Caller)
+	}
+
+	cl, ok := c.classes[className]
+	if !ok {
+		c.checkArgs(n, n.MethodName, nil, n.Args)
+		return ""
+	}
+	if n.IsField {
+		t, exists := cl.fields[n.MethodName]
+		if !exists || (static && !cl.static[n.MethodName]) {
+			c.errorf(n, "class '%s' has no field '%s'", className, n.MethodName)
+			return ""
+		}
+		return t
+	}
+
+	sig, exists := cl.methods[n.MethodName]
+	if !exists {
+		c.errorf(n, "class '%s' has no method '%s'", className, n.MethodName)
+		c.checkArgs(n, n.MethodName, nil, n.Args)
+		return ""
+	}
+	c.checkArgs(n, className+"->"+n.MethodName, sig, n.Args)
+	return sig.returns
+}
+
+// match checks every arm, the match has a type if all arms agree on it.
+func (c *Checker) match(n *parser.MatchNode) string {
+	c.expr(n.Subject)
+	result := ""
+	for i, arm := range n.Arms {
+		for _, pattern := range arm.Patterns {
+			c.pattern(pattern)
+		}
+		t := ""
+		if body, isBlock := arm.Body.(*parser.BlockNode); isBlock {
+			c.block(body)
+		} else {
+			t = c.expr(arm.Body)
+		}
+		if i == 0 {
+			result = t
+		} else if t != result {
+			result = ""
+		}
+	}
+	return result
+}
+
+func (c *Checker) pattern(pattern parser.Node) {
+	switch pat := pattern.(type) {
+	case *parser.ValuePattern:
+		c.expr(pat.Value)
+	case *parser.RangePattern:
+		c.expr(pat.Low)
+		c.expr(pat.High)
+	case *parser.TypePattern:
+		// a variable is matched by value, see parsePattern
+		if _, ok := c.lookup(pat.TypeName); ok && !c.isClass(pat.TypeName) {
+			return
+		}
+		if pat.TypeName != "array" {
+			c.validType(pat, pat.TypeName)
+		}
+	case *parser.StructPattern:
+		if c.validType(pat, pat.TypeName) {
+			for _, field := range pat.Fields {
+				c.pattern(field.Pattern)
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package check
+
+import "strings"
+
+// Builtin type names usable in annotations. Arrays are written '[]' for
+// any array or '[]T' for arrays of T.
+var builtinTypes = map[string]bool{
+	"int":    true,
+	"float":  true,
+	"string": true,
+	"bool":   true,
+	"nil":    true,
+	"any":    true,
+	"range":  true,
+	// returned by functions containing 'yield'
+	"generator": true,
+	// returned by 'spawn' and the 'channel' builtin
+	"task":    true,
+	"channel": true,
+}
+
+// Types that can't hold nil.
+var valueTypes = map[string]bool{
+	"int":       true,
+	"float":     true,
+	"string":    true,
+	"bool":      true,
+	"range":     true,
+	"generator": true,
+	"task":      true,
+	"channel":   true,
+}
+
+func isArrayType(t string) bool {
+	return strings.HasPrefix(t, "[]")
+}
+
+// elemType returns the element type of an array type, "" if unknown.
+func elemType(t string) string {
+	return strings.TrimPrefix(t, "[]")
+}
+
+func isNumeric(t string) bool {
+	return t == "int" || t == "float"
+}
+
+// Assignable reports whether a value of type source can be stored where
+// target is expected. "" stands for a type that is not known statically
+// and is assignable both ways, so unannotated code is never rejected.
+// Ints are promoted to float and nil fits classes and arrays.
+func Assignable(target, source string) bool {
+	switch {
+	case target == "" || source == "":
+		return true
+	case target == "any" || source == "any":
+		return true
+	case target == source:
+		return true
+	case target == "float" && source == "int":
+		return true
+	case source == "nil":
+		return !valueTypes[target]
+	case isArrayType(target) && isArrayType(source):
+		return Assignable(elemType(target), elemType(source))
+	}
+	return false
+}
@@ -26,6 +26,37 @@ type ReturnValue struct {
     Value any
 }
 
-type BreakSignal struct {}
+// BreakSignal and ContinueSignal travel up from 'break' and 'continue'
+// to the loop named by Label, or to the innermost loop if it is empty.
+type BreakSignal struct {
+	Label string
+	Pos   parser.Position
+}
+
+type ContinueSignal struct {
+	Label string
+	Pos   parser.Position
+}
 
 type NilValue struct {}
+
+// Range is the lazy sequence Start, Start+Step, ... stopping before End.
+type Range struct {
+	Start int
+	End   int
+	Step  int
+}
+
+func (r Range) Len() int {
+	switch {
+	case r.Step > 0 && r.End > r.Start:
+		return (r.End - r.Start + r.Step - 1) / r.Step
+	case r.Step < 0 && r.End < r.Start:
+		return (r.Start - r.End - r.Step - 1) / -r.Step
+	}
+	return 0
+}
+
+func (r Range) At(i int) int {
+	return r.Start + i*r.Step
+}
@@ -1,28 +1,33 @@
 package env
 
 import (
-	"lang/internal/core"
 	"lang/internal/parser"
 )
 
 func (e *Env) AddVarSymbol(name, varType string, val any) {
-	e.Symbols[name] = &VarSymbol{value: val, typeName: varType}
+	e.Set(name, &VarSymbol{value: val, typeName: varType})
+}
+
+// AddTypedVarSymbol adds a variable that only accepts values of the
+// declared type.
+func (e *Env) AddTypedVarSymbol(name, declared, varType string, val any) {
+	e.Set(name, &VarSymbol{value: val, typeName: varType, declared: declared})
 }
 
 func (e *Env) AddFuncSymbol(name string, params []string, body *parser.BlockNode, newEnv *Env) {
-	e.Symbols[name] = &FuncSymbol{
+	e.Set(name, &FuncSymbol{
 		Body:     body,
 		Params:   params,
 		TypeName: "nan",
 		Env:      newEnv,
-	}
+	})
 }
 
 func (e *Env) AddStructSymbol(name string, structEnv *Env) {
-	e.Symbols[name] = &StructSymbol{
+	e.Set(name, &StructSymbol{
 		TypeName:    name,
 		Environment: structEnv,
-	}
+	})
 }
 
 func (e *Env) AddStructMethod(
@@ -32,7 +37,7 @@ func (e *Env) AddStructMethod(
 	body []parser.Node,
 	nativeFn any) int {
 	for env := e; env != nil; env = env.Parent {
-		sym, exists := env.Symbols[structName]
+		sym, exists := env.Get(structName)
 		if !exists {
 			continue
 		}
@@ -42,11 +47,9 @@ func (e *Env) AddStructMethod(
 			return -1
 		}
 
-		if structSym.Environment.Symbols == nil {
-			structSym.Environment.Symbols = make(core.SymbolStore)
-		}
-
-		if _, exists := structSym.Environment.Symbols[methodName]; exists {
+		classEnv := structSym.Environment
+		defer classEnv.unlock(classEnv.lock())
+		if classEnv.slot(methodName) >= 0 {
 			return -1
 		}
 
@@ -57,8 +60,7 @@ func (e *Env) AddStructMethod(
 			Env:      NewEnv(structSym.Environment, "method"),
 		}
 
-		structSym.Environment.Symbols[methodName] = funcSym
-		env.Symbols[structName] = structSym
+		classEnv.set(methodName, funcSym)
 		return 0
 	}
 	return 1
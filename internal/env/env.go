@@ -4,27 +4,228 @@ import (
 	"fmt"
 	"lang/internal/core"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
+// Env is a scope of symbols. Spawned tasks share envs, so the symbols
+// and modifiers are only reached through methods holding mu once
+// Share has been called on an env of the same program. Fields and
+// Members are set once when a class or enum is declared.
+//
+// Symbols are kept in definition order, so a symbol keeps its slot for
+// the life of the env and can be found by index, see Slot. The first
+// few live in the Env itself, which makes the block and call scopes
+// created on every loop iteration a single allocation.
 type Env struct {
-	Type    string
-	Symbols map[string]core.Symbol
-	Parent  *Env
+	Type     string
+	Parent   *Env
+	bindings []binding
+	inline   [inlineBindings]binding
+	// slot by name, built once bindings outgrows linear search
+	index   map[string]int
+	private map[string]bool
+	static  map[string]bool
+	// declaration order of instance fields, set on class envs
+	Fields []string
+	// members in declaration order, set on enum envs
+	Members []*Env
+	mu      sync.RWMutex
+	// set once the envs of the program may be used by more than one
+	// goroutine, shared by every env under the same root
+	shared *atomic.Bool
+}
+
+type binding struct {
+	name string
+	sym  core.Symbol
+}
+
+const (
+	inlineBindings = 4
+	// scopes with more symbols than this get an index
+	indexThreshold = 8
+)
+
+// Share makes e and every env of the same program lock from now on.
+// Until then locking them is skipped, it's most of the cost of a lookup.
+// It has to be called before a second goroutine starts using them.
+func (e *Env) Share() {
+	e.shared.Store(true)
+}
+
+// Shared reports whether Share was called on an env of the same program
+// as e.
+func (e *Env) Shared() bool {
+	return e.shared.Load()
+}
+
+func (e *Env) lock() bool {
+	if !e.shared.Load() {
+		return false
+	}
+	e.mu.Lock()
+	return true
+}
+
+func (e *Env) unlock(locked bool) {
+	if locked {
+		e.mu.Unlock()
+	}
+}
+
+func (e *Env) rlock() bool {
+	if !e.shared.Load() {
+		return false
+	}
+	e.mu.RLock()
+	return true
+}
+
+func (e *Env) runlock(locked bool) {
+	if locked {
+		e.mu.RUnlock()
+	}
 }
 
 func NewEnv(parent *Env, envType string) *Env {
+	if parent == nil {
+		return NewDetachedEnv(envType, nil)
+	}
 	return &Env{
-		Type:    envType,
-		Symbols: make(map[string]core.Symbol),
-		Parent:  parent,
+		Type:   envType,
+		Parent: parent,
+		shared: parent.shared,
 	}
 }
 
+// NewDetachedEnv returns an env without a parent that is shared along
+// with the envs of program, or starts a new program if that is nil.
+func NewDetachedEnv(envType string, program *Env) *Env {
+	shared := new(atomic.Bool)
+	if program != nil {
+		shared = program.shared
+	}
+	return &Env{Type: envType, shared: shared}
+}
+
+// Reset empties e for reuse as a new scope of the given type under
+// parent. Only envs nothing else can reach anymore may be reset.
+func (e *Env) Reset(parent *Env, envType string) {
+	clear(e.bindings)
+	e.bindings = e.bindings[:0]
+	e.index = nil
+	e.Parent = parent
+	e.Type = envType
+	if parent != nil {
+		e.shared = parent.shared
+	}
+}
+
+// slot returns the index of name in e.bindings, -1 if it isn't defined
+// directly in e. e.mu has to be held.
+func (e *Env) slot(name string) int {
+	if e.index != nil {
+		if i, ok := e.index[name]; ok {
+			return i
+		}
+		return -1
+	}
+	for i := range e.bindings {
+		if e.bindings[i].name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Get returns a symbol defined directly in e.
+func (e *Env) Get(name string) (core.Symbol, bool) {
+	defer e.runlock(e.rlock())
+	if i := e.slot(name); i >= 0 {
+		return e.bindings[i].sym, true
+	}
+	return nil, false
+}
+
+// Symbol returns a symbol defined directly in e, nil if there is none.
+func (e *Env) Symbol(name string) core.Symbol {
+	sym, _ := e.Get(name)
+	return sym
+}
+
+// Set defines or replaces a symbol directly in e. A replaced symbol
+// keeps its slot.
+func (e *Env) Set(name string, sym core.Symbol) {
+	defer e.unlock(e.lock())
+	e.set(name, sym)
+}
+
+func (e *Env) set(name string, sym core.Symbol) {
+	if i := e.slot(name); i >= 0 {
+		e.bindings[i].sym = sym
+		return
+	}
+	if e.bindings == nil {
+		e.bindings = e.inline[:0]
+	}
+	e.bindings = append(e.bindings, binding{name: name, sym: sym})
+	switch {
+	case e.index != nil:
+		e.index[name] = len(e.bindings) - 1
+	case len(e.bindings) > indexThreshold:
+		e.reindex()
+	}
+}
+
+func (e *Env) reindex() {
+	e.index = make(map[string]int, len(e.bindings))
+	for i, b := range e.bindings {
+		e.index[b.name] = i
+	}
+}
+
+// Slot returns the symbol in slot i of the env depth levels up from e,
+// if it is named name. It is the fast path for variables whose slot was
+// worked out before running, see internal/resolver.
+func (e *Env) Slot(depth, i int, name string) (core.Symbol, *Env, bool) {
+	env := e
+	for ; depth > 0 && env != nil; depth-- {
+		env = env.Parent
+	}
+	if env == nil {
+		return nil, nil, false
+	}
+	defer env.runlock(env.rlock())
+	if i < len(env.bindings) && env.bindings[i].name == name {
+		return env.bindings[i].sym, env, true
+	}
+	return nil, nil, false
+}
+
+// Each calls fn with the symbols defined directly in e. It works on a
+// snapshot, so fn may modify e.
+func (e *Env) Each(fn func(name string, sym core.Symbol)) {
+	locked := e.rlock()
+	snapshot := make([]binding, len(e.bindings))
+	copy(snapshot, e.bindings)
+	e.runlock(locked)
+	for _, b := range snapshot {
+		fn(b.name, b.sym)
+	}
+}
+
+// Len returns the number of symbols defined directly in e.
+func (e *Env) Len() int {
+	defer e.runlock(e.rlock())
+	return len(e.bindings)
+}
+
 func (e *Env) ListSymbols() string {
 	var lines []string
-	for name, val := range e.Symbols {
+	e.Each(func(name string, val core.Symbol) {
 		lines = append(lines, fmt.Sprintf("%s(%T) -> %v\n", name, val.Type(), val))
-	}
+	})
 	return strings.Join(lines, "\n")
 }
 
@@ -33,7 +234,7 @@ func UnwrapBuiltinValue(v any) any {
 		if instEnv.Parent != nil {
 			pName := instEnv.Parent.Type
 			if pName == "string" || pName == "int" || pName == "float" {
-				if valueSym, ok := instEnv.Symbols["value"]; ok {
+				if valueSym, ok := instEnv.Get("value"); ok {
 					return valueSym.Value()
 				}
 			}
@@ -2,10 +2,14 @@ package env
 
 func (e *Env) ChangeArrayValue(name string, index int, value any) {
 	for env := e; env != nil; env = env.Parent {
-		sym, ok := env.Symbols[name]
-		if !ok {
+		locked := env.lock()
+		i := env.slot(name)
+		if i < 0 {
+			env.unlock(locked)
 			continue
 		}
+		sym := env.bindings[i].sym
+		defer env.unlock(locked)
 		varSym, ok := sym.(*VarSymbol)
 		if !ok {
 			return
@@ -15,7 +19,7 @@ func (e *Env) ChangeArrayValue(name string, index int, value any) {
 			return
 		}
 		arr[index] = value
-		env.Symbols[name] = &VarSymbol{value: arr, typeName: varSym.typeName}
+		env.bindings[i].sym = &VarSymbol{value: arr, typeName: varSym.typeName, declared: varSym.declared}
 		return
 	}
 }
@@ -0,0 +1,29 @@
+package env
+
+// SetPrivate marks a class (or module) member as private.
+func (e *Env) SetPrivate(name string) {
+	defer e.unlock(e.lock())
+	if e.private == nil {
+		e.private = make(map[string]bool)
+	}
+	e.private[name] = true
+}
+
+func (e *Env) IsPrivate(name string) bool {
+	defer e.runlock(e.rlock())
+	return e.private[name]
+}
+
+// SetStatic marks a class member as shared by the class and its instances.
+func (e *Env) SetStatic(name string) {
+	defer e.unlock(e.lock())
+	if e.static == nil {
+		e.static = make(map[string]bool)
+	}
+	e.static[name] = true
+}
+
+func (e *Env) IsStatic(name string) bool {
+	defer e.runlock(e.rlock())
+	return e.static[name]
+}
@@ -1,15 +1,18 @@
 package env
 
-import "lang/internal/core"
+import (
+	"lang/internal/core"
+	"slices"
+)
 
 func (e *Env) SymbolExistsInCurrent(name string) bool {
-	_, exists := e.Symbols[name]
+	_, exists := e.Get(name)
 	return exists
 }
 
 func (e *Env) SymbolExists(name string) bool {
 	for env := e; env != nil; env = env.Parent {
-		if _, exists := env.Symbols[name]; exists {
+		if _, exists := env.Get(name); exists {
 			return true
 		}
 		if env.Type == "block" && env.Parent != nil && env.Parent.Type == "global" {
@@ -21,7 +24,7 @@ func (e *Env) SymbolExists(name string) bool {
 
 func (e *Env) IsSymbolFunc(name string) bool {
 	for env := e; env != nil; env = env.Parent {
-		if sym, ok := env.Symbols[name]; ok {
+		if sym, ok := env.Get(name); ok {
 			_, isFunc := sym.(*FuncSymbol)
 			return isFunc
 		}
@@ -31,7 +34,7 @@ func (e *Env) IsSymbolFunc(name string) bool {
 
 func (e *Env) IsSymbolArray(name string) bool {
 	for env := e; env != nil; env = env.Parent {
-		if sym, ok := env.Symbols[name]; ok {
+		if sym, ok := env.Get(name); ok {
 			_, isArray := sym.Value().([]any)
 			return isArray
 		}
@@ -41,7 +44,7 @@ func (e *Env) IsSymbolArray(name string) bool {
 
 func (e *Env) GetSymbolType(name string) string {
 	for env := e; env != nil; env = env.Parent {
-		if sym, ok := env.Symbols[name]; ok {
+		if sym, ok := env.Get(name); ok {
 			return sym.Type()
 		}
 	}
@@ -49,20 +52,26 @@ func (e *Env) GetSymbolType(name string) string {
 }
 
 func (e *Env) FindSymbol(name string) core.Symbol {
+	sym, _ := e.Lookup(name)
+	return sym
+}
+
+// Lookup is FindSymbol that also returns the env defining the symbol.
+func (e *Env) Lookup(name string) (core.Symbol, *Env) {
 	for env := e; env != nil; env = env.Parent {
-		if symValue, ok := env.Symbols[name]; ok {
-			return symValue
+		if symValue, ok := env.Get(name); ok {
+			return symValue, env
 		}
 		if env.Type == "block" && env.Parent != nil && env.Parent.Type == "global" {
 			break
 		}
 	}
-	return nil
+	return nil, nil
 }
 
 func (e *Env) FindStructSymbol(name string) *Env {
 	for env := e; env != nil; env = env.Parent {
-		sym, ok := env.Symbols[name]
+		sym, ok := env.Get(name)
 		if !ok {
 			continue
 		}
@@ -77,7 +86,7 @@ func (e *Env) FindStructSymbol(name string) *Env {
 
 func (e *Env) FindStructMember(structName, memberName string) core.Symbol {
 	for env := e; env != nil; env = env.Parent {
-		sym, ok := env.Symbols[structName]
+		sym, ok := env.Get(structName)
 		if !ok {
 			continue
 		}
@@ -85,7 +94,7 @@ func (e *Env) FindStructMember(structName, memberName string) core.Symbol {
 		if !ok {
 			return nil
 		}
-		if member, ok := structSym.Environment.Symbols[memberName]; ok {
+		if member, ok := structSym.Environment.Get(memberName); ok {
 			return member
 		}
 		return nil
@@ -93,25 +102,52 @@ func (e *Env) FindStructMember(structName, memberName string) core.Symbol {
 	return nil
 }
 
+// RemoveSymbol deletes name from e, moving the symbols defined after it
+// down a slot.
 func (e *Env) RemoveSymbol(name string) {
-	delete(e.Symbols, name)
+	defer e.unlock(e.lock())
+	i := e.slot(name)
+	if i < 0 {
+		return
+	}
+	e.bindings = slices.Delete(e.bindings, i, i+1)
+	if e.index != nil {
+		e.reindex()
+	}
 }
 
 func (e *Env) UpdateSymbol(name string, newValue any, newType string) {
 	for env := e; env != nil; env = env.Parent {
-		if sym, ok := env.Symbols[name]; ok {
-			if env.IsSymbolFunc(name) {
-				break
-			}
-			varType := sym.Type()
-			if len(newType) != 0 {
-				varType = newType
-			}
-			env.Symbols[name] = &VarSymbol{
-				value: newValue,
-				typeName: varType,
-			}
+		if env.UpdateInCurrent(name, newValue, newType) {
+			return
 		}
 	}
 }
 
+// UpdateInCurrent replaces the value of name if it is defined directly
+// in e, and reports whether it was. Functions are never replaced.
+func (e *Env) UpdateInCurrent(name string, newValue any, newType string) bool {
+	defer e.unlock(e.lock())
+	i := e.slot(name)
+	if i < 0 {
+		return false
+	}
+	sym := e.bindings[i].sym
+	if _, isFunc := sym.(*FuncSymbol); isFunc {
+		return true
+	}
+	varType := sym.Type()
+	if len(newType) != 0 {
+		varType = newType
+	}
+	declared := ""
+	if varSym, ok := sym.(*VarSymbol); ok {
+		declared = varSym.declared
+	}
+	e.bindings[i].sym = &VarSymbol{
+		value:    newValue,
+		typeName: varType,
+		declared: declared,
+	}
+	return true
+}
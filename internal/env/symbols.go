@@ -26,6 +26,15 @@ type FuncSymbol struct {
 	Params     []string
 	TypeName   string
 	Env        *Env
+	// annotations, "" where none was given
+	ParamTypes []string
+	ReturnType string
+	// default values, nil for required parameters
+	Defaults []parser.Node
+	// the last parameter collects the remaining args in an array
+	Variadic bool
+	// the body contains 'yield', calls return a generator
+	Generator bool
 	NativeFunc func(e core.Evaluator, self *Env, args []any, pos parser.Position) any
 }
 
@@ -39,7 +48,11 @@ func (f *FuncSymbol) Type() string   { return f.TypeName }
 type VarSymbol struct {
 	value    any
 	typeName string
+	// type annotation the variable was declared with, kept across
+	// assignments
+	declared string
 }
 
 func (v *VarSymbol) Value() any     { return v.value }
 func (v *VarSymbol) Type() string   { return v.typeName }
+func (v *VarSymbol) Declared() string { return v.declared }
@@ -26,6 +26,12 @@ func (e *Evaluator) evalArrayAccess(stmt *parser.ArrayAccessNode) any {
 
 	// Evaluate the index expression
 	idx := unwrapBuiltinValue(e.EvalNode(stmt.Index))
+	if idx == nil {
+		return nil
+	}
+	if inst, ok := operatorMethod(arr, "index"); ok {
+		return e.invokeOperator(inst, "index", []any{idx}, stmt.Position)
+	}
 	i, ok := idx.(int)
 	if !ok {
 		e.GenError("Array index must be an integer", stmt.Position)
@@ -34,14 +40,14 @@ func (e *Evaluator) evalArrayAccess(stmt *parser.ArrayAccessNode) any {
 
 	// Check if arr is actually an array
 	switch a := arr.(type) {
-	case []any:
+	case []any, []int, []float64:
 		{
-			if i < 0 || i >= len(a) {
+			if i < 0 || i >= arrayLen(a) {
 				e.GenError(fmt.Sprintf("Index %d out of bounds", i),
 					stmt.Position)
 				return nil
 			}
-			return a[i]
+			return e.arrayGet(a, i)
 		}
 	case string:
 		{
@@ -94,15 +100,14 @@ func (e *Evaluator) evalArrayAssign(stmt *parser.ArrayAssign) any {
 	}
 
 	// parent should be an array
-	arr, ok := parent.([]any)
-	if !ok {
+	if !isArray(parent) {
 		e.GenError(fmt.Sprintf(
 			"Target has incorrect type. It should be array: %T",
 			parent,
 		), stmt.Position)
 		return nil
 	}
-	if index < 0 || index >= len(arr) {
+	if index < 0 || index >= arrayLen(parent) {
 		e.GenError(fmt.Sprintf("Index %d out of bounds", index),
 			stmt.Position)
 		return nil
@@ -110,9 +115,44 @@ func (e *Evaluator) evalArrayAssign(stmt *parser.ArrayAssign) any {
 
 	// Assign the value
 	val := e.EvalNode(stmt.Value)
-	arr[index] = val
+	if err := e.arraySet(parent, index, unwrapBuiltinValue(val)); err != nil {
+		e.GenError(err.Error(), stmt.Position)
+		return nil
+	}
 	e.currentEnv.UpdateSymbol(stmt.Target.String(),
-		arr, e.resolveType(arr, stmt.Position))
+		parent, e.resolveType(parent, stmt.Position))
 
 	return core.NilValue{}
 }
+
+// assignNestedElement handles 'm[i][j] = v': the inner array is written
+// in place, so only existing indexes can be set.
+func (e *Evaluator) assignNestedElement(
+	inner any,
+	index int,
+	valueNode parser.Node,
+	pos parser.Position) any {
+
+	inner = unwrapBuiltinValue(inner)
+	if !isArray(inner) {
+		e.GenError(fmt.Sprintf(
+			"Target has incorrect type. It should be array: %T",
+			inner,
+		), pos)
+		return nil
+	}
+	if index < 0 || index >= arrayLen(inner) {
+		e.GenError(fmt.Sprintf("Index %d out of bounds", index), pos)
+		return nil
+	}
+
+	value := unwrapBuiltinValue(e.EvalNode(valueNode))
+	if value == nil {
+		return nil
+	}
+	if err := e.arraySet(inner, index, value); err != nil {
+		e.GenError(err.Error(), pos)
+		return nil
+	}
+	return value
+}
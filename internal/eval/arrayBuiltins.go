@@ -0,0 +1,277 @@
+package eval
+
+import (
+	"fmt"
+	"lang/internal/core"
+	"lang/internal/env"
+	"lang/internal/parser"
+	"math"
+	"math/bits"
+	"sync"
+	"unsafe"
+)
+
+// builtinArray implements 'array(n, fill)', and 'array(iterable)' which
+// collects a generator, channel, range or string into a new array.
+// Int and float fills are stored unboxed as []int / []float64.
+func builtinArray(e *Evaluator, args []parser.Node, pos parser.Position) any {
+	if len(args) < 1 || len(args) > 2 {
+		e.GenError("array: expects one or two arguments", pos)
+		return nil
+	}
+	first := e.EvalNode(args[0])
+	if first == nil {
+		return nil
+	}
+	if len(args) == 1 {
+		// generators and channels are checked as they run
+		switch v := unwrapBuiltinValue(first).(type) {
+		case *generator, *channel:
+			return e.collect(v, pos)
+		case core.Range:
+			if !e.reserve(arraySize(1, v.Len()), pos) {
+				return nil
+			}
+			return e.collect(v, pos)
+		case string:
+			if !e.reserve(arraySize(1, len(v)), pos) {
+				return nil
+			}
+			return e.collect(v, pos)
+		}
+	}
+	n, ok := env.UnwrapBuiltinValue(first).(int)
+	if !ok || n < 0 {
+		e.GenError("array: size must be a non-negative int", pos)
+		return nil
+	}
+	if !e.reserve(arraySize(1, n), pos) {
+		return nil
+	}
+
+	var fill any = core.NilValue{}
+	if len(args) == 2 {
+		fill = e.EvalNode(args[1])
+		if fill == nil {
+			return nil
+		}
+	}
+
+	return e.newFilledArray(n, fill)
+}
+
+// builtinMatrix implements 'matrix(rows, cols, fill)'.
+// Every row is a separate array so rows never alias each other.
+func builtinMatrix(e *Evaluator, args []parser.Node, pos parser.Position) any {
+	if len(args) < 2 || len(args) > 3 {
+		e.GenError("matrix: expects two or three arguments", pos)
+		return nil
+	}
+	rows, ok1 := env.UnwrapBuiltinValue(e.EvalNode(args[0])).(int)
+	cols, ok2 := env.UnwrapBuiltinValue(e.EvalNode(args[1])).(int)
+	if !ok1 || !ok2 || rows < 0 || cols < 0 {
+		e.GenError("matrix: dimensions must be non-negative ints", pos)
+		return nil
+	}
+	if !e.reserve(arraySize(rows, cols), pos) {
+		return nil
+	}
+
+	var fill any = core.NilValue{}
+	if len(args) == 3 {
+		fill = e.EvalNode(args[2])
+		if fill == nil {
+			return nil
+		}
+	}
+
+	m := make([]any, rows)
+	for i := range m {
+		m[i] = e.newFilledArray(cols, fill)
+	}
+	return m
+}
+
+// arraySlotSize is the size of an array element as reserved against a
+// sandbox memory limit, that of a boxed value.
+const arraySlotSize = 16
+
+// arraySize is the size reserved for rows arrays of cols elements, the
+// largest size when it doesn't fit.
+func arraySize(rows, cols int) uint64 {
+	hi, n := bits.Mul64(uint64(rows), uint64(cols))
+	if hi != 0 || n > math.MaxUint64/arraySlotSize {
+		return math.MaxUint64
+	}
+	return n * arraySlotSize
+}
+
+func (e *Evaluator) newFilledArray(n int, fill any) any {
+	switch f := unwrapBuiltinValue(fill).(type) {
+	case int:
+		arr := make([]int, n)
+		if f != 0 {
+			for i := range arr {
+				arr[i] = f
+			}
+		}
+		return arr
+	case float64:
+		arr := make([]float64, n)
+		if f != 0 {
+			for i := range arr {
+				arr[i] = f
+			}
+		}
+		return arr
+	case string:
+		// strings are mutable instances, each slot gets its own
+		arr := make([]any, n)
+		for i := range arr {
+			arr[i] = e.createString(f)
+		}
+		return arr
+	default:
+		arr := make([]any, n)
+		for i := range arr {
+			arr[i] = copyArray(fill)
+		}
+		return arr
+	}
+}
+
+// copyArray returns a shallow copy of array values, other values as is.
+func copyArray(v any) any {
+	switch a := v.(type) {
+	case []any:
+		return append([]any(nil), a...)
+	case []int:
+		return append([]int(nil), a...)
+	case []float64:
+		return append([]float64(nil), a...)
+	}
+	return v
+}
+
+func isArray(v any) bool {
+	switch v.(type) {
+	case []any, []int, []float64:
+		return true
+	}
+	return false
+}
+
+func arrayLen(v any) int {
+	switch a := v.(type) {
+	case []any:
+		return len(a)
+	case []int:
+		return len(a)
+	case []float64:
+		return len(a)
+	}
+	return 0
+}
+
+// arrayLocks guard the elements of arrays once envs are shared, tasks
+// may read and write the same array. Arrays are slices with nowhere to
+// keep a lock, so each uses the one its elements' address picks.
+type arrayLocks [64]sync.RWMutex
+
+func (e *Evaluator) arrayLocks() *arrayLocks {
+	if e.arrays == nil {
+		e.arrays = new(arrayLocks)
+	}
+	return e.arrays
+}
+
+// arrayLock returns the lock of arr, nil while envs aren't shared.
+func (e *Evaluator) arrayLock(arr any) *sync.RWMutex {
+	if !e.Environment.Shared() {
+		return nil
+	}
+	var elems uintptr
+	switch a := arr.(type) {
+	case []any:
+		elems = uintptr(unsafe.Pointer(unsafe.SliceData(a)))
+	case []int:
+		elems = uintptr(unsafe.Pointer(unsafe.SliceData(a)))
+	case []float64:
+		elems = uintptr(unsafe.Pointer(unsafe.SliceData(a)))
+	}
+	locks := e.arrayLocks()
+	// the low bits are mostly the same, allocations are aligned
+	return &locks[elems>>6%uintptr(len(locks))]
+}
+
+func (e *Evaluator) arrayGet(v any, i int) any {
+	if mu := e.arrayLock(v); mu != nil {
+		mu.RLock()
+		defer mu.RUnlock()
+	}
+	switch a := v.(type) {
+	case []any:
+		return a[i]
+	case []int:
+		return a[i]
+	case []float64:
+		return a[i]
+	}
+	return nil
+}
+
+// arraySet stores value at i. Typed arrays only accept their element
+// type, ints are promoted when stored in a float array.
+func (e *Evaluator) arraySet(arr any, i int, value any) error {
+	if mu := e.arrayLock(arr); mu != nil {
+		mu.Lock()
+		defer mu.Unlock()
+	}
+	switch a := arr.(type) {
+	case []any:
+		a[i] = value
+		return nil
+	case []int:
+		if v, ok := value.(int); ok {
+			a[i] = v
+			return nil
+		}
+	case []float64:
+		switch v := value.(type) {
+		case float64:
+			a[i] = v
+			return nil
+		case int:
+			a[i] = float64(v)
+			return nil
+		}
+	default:
+		return fmt.Errorf("Target has incorrect type. It should be array: %T", arr)
+	}
+	return fmt.Errorf("Cannot store %T in array of type %T", value, arr)
+}
+
+func (e *Evaluator) arrayAppend(arr any, value any) (any, error) {
+	if mu := e.arrayLock(arr); mu != nil {
+		mu.Lock()
+		defer mu.Unlock()
+	}
+	switch a := arr.(type) {
+	case []any:
+		return append(a, value), nil
+	case []int:
+		if v, ok := value.(int); ok {
+			return append(a, v), nil
+		}
+	case []float64:
+		switch v := value.(type) {
+		case float64:
+			return append(a, v), nil
+		case int:
+			return append(a, float64(v)), nil
+		}
+	default:
+		return nil, fmt.Errorf("Target has incorrect type. It should be array: %T", arr)
+	}
+	return nil, fmt.Errorf("Cannot store %T in array of type %T", value, arr)
+}
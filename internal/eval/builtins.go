@@ -6,8 +6,6 @@ import (
 	"lang/internal/core"
 	"lang/internal/env"
 	"lang/internal/parser"
-	"net/http"
-	"os"
 	"strconv"
 	"strings"
 )
@@ -15,59 +13,26 @@ import (
 type BuiltinFunction func(e *Evaluator,
 	args []parser.Node, pos parser.Position) any
 
-func decodeEscapeSequences(input string) (string, error) {
-	// Wrap in quotes to make it a valid Go string literal
-	quoted := "\"" + input + "\""
-	return strconv.Unquote(quoted)
-}
-
 func builtinPrint(e *Evaluator, args []parser.Node, pos parser.Position) any {
+	var out strings.Builder
 	for _, arg := range args {
-		val := env.UnwrapBuiltinValue(e.EvalNode(arg))
-		fmt.Print(val)
-	}
-	return core.NilValue{}
-}
-
-func builtinPrintf(e *Evaluator, args []parser.Node, pos parser.Position) any {
-	for _, arg := range args {
-		val := env.UnwrapBuiltinValue(e.EvalNode(arg))
-		if s, ok := val.(string); ok {
-			decoded, err := decodeEscapeSequences(s)
-			if err != nil {
-				e.GenError(
-					"print: invalid escape sequence",
-					pos,
-				)
-				return nil
-			}
-			fmt.Print(decoded)
-		} else {
-			fmt.Print(val)
-		}
+		val := e.EvalNode(arg)
+		out.WriteString(e.formatValue(val, pos))
 	}
-	fmt.Println()
+	e.print(out.String())
 	return core.NilValue{}
 }
 
+// builtinPrintln prints like print followed by a newline, and is also
+// printf. Escapes in strings were decoded when their literals were read.
 func builtinPrintln(e *Evaluator, args []parser.Node, pos parser.Position) any {
+	var out strings.Builder
 	for _, arg := range args {
-		val := env.UnwrapBuiltinValue(e.EvalNode(arg))
-		if s, ok := val.(string); ok {
-			decoded, err := decodeEscapeSequences(s)
-			if err != nil {
-				e.GenError(
-					"println: invalid escape sequence",
-					pos,
-				)
-				return nil
-			}
-			fmt.Print(decoded)
-		} else {
-			fmt.Print(val)
-		}
+		val := e.EvalNode(arg)
+		out.WriteString(e.formatValue(val, pos))
 	}
-	fmt.Println()
+	out.WriteString("\n")
+	e.print(out.String())
 	return core.NilValue{}
 }
 
@@ -86,10 +51,12 @@ func builtinInput(e *Evaluator, args []parser.Node, pos parser.Position) any {
 		return nil
 	}
 	if len(args) == 1 {
-		fmt.Print(env.UnwrapBuiltinValue(e.EvalNode(args[0])))
+		e.print(fmt.Sprint(env.UnwrapBuiltinValue(e.EvalNode(args[0]))))
+	}
+	input, ok := e.readInput()
+	if !ok {
+		return nil
 	}
-	var input string
-	fmt.Scanln(&input)
 	return input
 }
 
@@ -111,6 +78,13 @@ func builtinInt(e *Evaluator, args []parser.Node, pos parser.Position) any {
 		return int(s)
 	case int:
 		return s
+	case *env.Env:
+		if inst, ok := operatorMethod(s, "toInt"); ok {
+			return e.callOperator(inst, "toInt", nil, pos)
+		}
+		e.GenError(fmt.Sprintf(
+			"int: class '%s' has no 'toInt' method", s.Type), pos)
+		return nil
 	default:
 		e.GenError(fmt.Sprintf(
 			"Unsupported type: %T", s), pos)
@@ -148,18 +122,11 @@ func builtinString(e *Evaluator, args []parser.Node, pos parser.Position) any {
 		e.GenError("itoa: expects one argument", pos)
 		return nil
 	}
-	val := env.UnwrapBuiltinValue(e.EvalNode(args[0]))
-	switch v := val.(type) {
-	case int:
-		return e.createString(strconv.Itoa(v))
-	case float64:
-		return e.createString(strconv.FormatFloat(v, 'g', -1, 64))
-	case string:
-		return e.createString(v)
-	default:
-		e.GenError(fmt.Sprintf("Unsupported type: %T", v), pos)
+	val := e.EvalNode(args[0])
+	if val == nil {
 		return nil
 	}
+	return e.createString(e.formatValue(val, pos))
 }
 
 func builtinLen(e *Evaluator, args []parser.Node, pos parser.Position) any {
@@ -169,12 +136,14 @@ func builtinLen(e *Evaluator, args []parser.Node, pos parser.Position) any {
 	}
 	arr := env.UnwrapBuiltinValue(e.EvalNode(args[0]))
 	switch a := arr.(type) {
-	case []any:
-		return len(a)
+	case []any, []int, []float64:
+		return arrayLen(a)
 	case string:
 		return len(a)
+	case core.Range:
+		return a.Len()
 	}
-	e.GenError("len: argument must be array/string", pos)
+	e.GenError("len: argument must be array/string/range", pos)
 	return nil
 }
 
@@ -193,7 +162,7 @@ func builtinReadAll(e *Evaluator, args []parser.Node, pos parser.Position) any {
 		return nil
 	}
 
-	data, err := os.ReadFile(fileName)
+	data, err := e.readFile(fileName)
 	if err != nil {
 		e.GenError(err.Error(), pos)
 		return nil
@@ -215,11 +184,11 @@ func builtinFetch(e *Evaluator, args []parser.Node, pos parser.Position) any {
 			"Argument should be string", pos)
 		return nil
 	}
-	if !strings.HasPrefix("https://", name) {
+	if !strings.HasPrefix(name, "https://") && !strings.HasPrefix(name, "http://") {
 		name = "https://" + name
 	}
 
-	resp, err := http.Get(name)
+	resp, err := e.get(name)
 	if err != nil {
 		e.GenError(fmt.Sprintf("Failed to fetch: %s", err), pos)
 		return nil
@@ -239,8 +208,8 @@ func builtinWrite(e *Evaluator, args []parser.Node, pos parser.Position) any {
 			"Function 'write' expect two arguments", pos)
 		return nil
 	}
-	EvalNodeedFileName := e.EvalNode(args[0])
-	EvalNodeedValue := e.EvalNode(args[1])
+	EvalNodeedFileName := unwrapBuiltinValue(e.EvalNode(args[0]))
+	EvalNodeedValue := unwrapBuiltinValue(e.EvalNode(args[1]))
 	if fileName, ok := EvalNodeedFileName.(string); ok {
 		var value []byte
 		switch val := EvalNodeedValue.(type) {
@@ -260,8 +229,11 @@ func builtinWrite(e *Evaluator, args []parser.Node, pos parser.Position) any {
 				return nil
 			}
 		}
-		data := os.WriteFile(fileName, value, 0644)
-		return data
+		if err := e.writeFile(fileName, value); err != nil {
+			e.GenError(err.Error(), pos)
+			return nil
+		}
+		return core.NilValue{}
 	} else {
 		e.GenError(fmt.Sprintf(
 			"Argument in 'readAll' should be file name as string, but got: %v",
@@ -309,21 +281,21 @@ func builtinOrd(e *Evaluator, args []parser.Node, pos parser.Position) any {
 }
 
 func (e *Evaluator) initBuiltintClasses() {
-	stringEnv := env.NewEnv(nil, "string")
+	stringEnv := env.NewDetachedEnv("string", e.Environment)
 	stringEnv.AddVarSymbol(
 		"value",
 		"string",
 		nil)
 	e.currentEnv.AddStructSymbol("string", stringEnv)
 
-	intEnv := env.NewEnv(nil, "int")
+	intEnv := env.NewDetachedEnv("int", e.Environment)
 	intEnv.AddVarSymbol(
 		"value",
 		"int",
 		nil)
 	e.currentEnv.AddStructSymbol("int", intEnv)
 
-	floatEnv := env.NewEnv(nil, "float")
+	floatEnv := env.NewDetachedEnv("float", e.Environment)
 	floatEnv.AddVarSymbol(
 		"value",
 		"float",
@@ -333,7 +305,7 @@ func (e *Evaluator) initBuiltintClasses() {
 
 func (e *Evaluator) initBuiltinMethods() {
 	builtins := map[string]BuiltinFunction{
-		"printf":  builtinPrintf,
+		"printf":  builtinPrintln,
 		"print":   builtinPrint,
 		"println": builtinPrintln,
 		"type":    builtinType,
@@ -347,6 +319,19 @@ func (e *Evaluator) initBuiltinMethods() {
 		"fetch":   builtinFetch,
 		"mod":     builtinMod,
 		"ord":     builtinOrd,
+		"array":   builtinArray,
+		"matrix":  builtinMatrix,
+		"range":   builtinRange,
+		"join":    builtinJoin,
+		"wait":    builtinWait,
+		"channel": builtinChannel,
+		"send":    builtinSend,
+		"recv":    builtinRecv,
+		"close":   builtinClose,
+		"sleep":   builtinSleep,
+		"setTimeout":  builtinSetTimeout,
+		"setInterval": builtinSetInterval,
+		"clearTimer":  builtinClearTimer,
 	}
 
 	e.Builtins = builtins
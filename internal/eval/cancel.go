@@ -0,0 +1,167 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"lang/internal/env"
+	"lang/internal/parser"
+	"sync"
+	"time"
+)
+
+// ErrCancelled is wrapped by the error a program stops with once the
+// context passed to EvalContext or CallContext is done, together with
+// the context's own error.
+var ErrCancelled = errors.New("evaluation cancelled")
+
+// interrupt stops a running program from outside of it, once its
+// context is done or a sandbox limit is broken. It's shared with the
+// evaluators of spawned tasks.
+type interrupt struct {
+	// closed once the program has to stop
+	done chan struct{}
+	once sync.Once
+	err  error
+	// unregisters the context callback
+	release func() bool
+	// done with done, for the requests the program makes
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// halt stops the program, err is reported once it has unwound.
+func (i *interrupt) halt(err error) {
+	i.once.Do(func() {
+		i.err = err
+		close(i.done)
+		i.cancel()
+	})
+}
+
+func (i *interrupt) halted() bool {
+	select {
+	case <-i.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (e *Evaluator) halted() bool {
+	return e.interrupt != nil && e.interrupt.halted()
+}
+
+// interrupted is closed once the program is stopped, so builtins that
+// block can stop waiting. Without a context or sandbox it is nil and
+// never ready.
+func (e *Evaluator) interrupted() <-chan struct{} {
+	if e.interrupt == nil {
+		return nil
+	}
+	return e.interrupt.done
+}
+
+// context is done once the program is stopped.
+func (e *Evaluator) context() context.Context {
+	if e.interrupt == nil {
+		return context.Background()
+	}
+	return e.interrupt.ctx
+}
+
+// pause sleeps for d and reports whether the program may go on.
+func (e *Evaluator) pause(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-e.interrupted():
+		return false
+	}
+}
+
+// begin prepares a run that stops once ctx is done, within the limits
+// of the sandbox.
+func (e *Evaluator) begin(ctx context.Context) bool {
+	if ctx.Done() == nil && e.Sandbox == nil {
+		return true
+	}
+	i := &interrupt{done: make(chan struct{})}
+	i.ctx, i.cancel = context.WithCancel(context.Background())
+	if ctx.Done() != nil {
+		cancel := func() {
+			i.halt(fmt.Errorf("%w: %w", ErrCancelled, context.Cause(ctx)))
+		}
+		i.release = context.AfterFunc(ctx, cancel)
+		// a context that is already done doesn't get to run anything
+		if ctx.Err() != nil {
+			cancel()
+		}
+	}
+	e.interrupt = i
+	if e.Sandbox != nil && !e.startSandbox() {
+		e.end()
+		return false
+	}
+	return true
+}
+
+// end finishes a run started by begin and reports why it was stopped,
+// if it was.
+func (e *Evaluator) end() error {
+	i := e.interrupt
+	if i == nil {
+		return nil
+	}
+	if i.release != nil {
+		i.release()
+	}
+	i.cancel()
+	if e.limits != nil {
+		e.stopSandbox()
+	}
+	e.interrupt = nil
+	if !i.halted() {
+		return nil
+	}
+	e.Errors = append(e.Errors, i.err)
+	return i.err
+}
+
+// Call calls a function the program defined with args, see CallContext.
+func (e *Evaluator) Call(name string, args ...any) (any, error) {
+	return e.CallContext(context.Background(), name, args...)
+}
+
+// CallContext calls a function the program defined with args and stops
+// it once ctx is done, like EvalContext. The arguments are converted by
+// ToValue and the result by FromValue. The errors of the call are
+// joined into the returned error.
+func (e *Evaluator) CallContext(ctx context.Context, name string, args ...any) (any, error) {
+	f, ok := e.Environment.FindSymbol(name).(*env.FuncSymbol)
+	if !ok {
+		return nil, fmt.Errorf("function '%s' not found", name)
+	}
+	values := make([]any, len(args))
+	for i, arg := range args {
+		value, err := e.ToValue(arg)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	errs := len(e.Errors)
+	if !e.begin(ctx) {
+		return nil, errors.Join(e.Errors[errs:]...)
+	}
+	e.currentEnv = e.Environment
+	result := e.callFunction(f, name, values, parser.Position{})
+	e.runEventLoop()
+	e.end()
+	if len(e.Errors) > errs || result == nil {
+		return nil, errors.Join(e.Errors[errs:]...)
+	}
+	return e.FromValue(result), nil
+}
@@ -0,0 +1,395 @@
+package eval
+
+import (
+	"fmt"
+	"lang/internal/core"
+	"lang/internal/env"
+	"lang/internal/parser"
+	"strconv"
+	"sync"
+)
+
+// task is the value of 'spawn f(args)'. The call runs on its own
+// goroutine with a forked evaluator, sharing only the environments.
+type task struct {
+	name   string
+	done   chan struct{}
+	result any
+	errors []error
+
+	mu sync.Mutex
+	// set once the errors of the task have been passed to an evaluator
+	reported bool
+}
+
+// channel is the value returned by 'channel()' and 'channel(size)'.
+type channel struct {
+	ch chan any
+}
+
+// fork returns an evaluator for a new goroutine, evaluating in scope.
+func (e *Evaluator) fork(scope *env.Env) *Evaluator {
+	return &Evaluator{
+		Entry:        e.Entry,
+		Environment:  e.Environment,
+		currentEnv:   scope,
+		parser:       e.parser,
+		lexer:        e.lexer,
+		Builtins:     e.Builtins,
+		loop:         e.loop,
+		MaxCallDepth: e.MaxCallDepth,
+		Sandbox:      e.Sandbox,
+		limits:       e.limits,
+		interrupt:    e.interrupt,
+		host:         e.host,
+		Stdin:        e.Stdin,
+		Stdout:       e.Stdout,
+		Stderr:       e.Stderr,
+		outMu:        e.outputLock(),
+		arrays:       e.arrayLocks(),
+		in:           e.inputs(),
+		bodies:       e.generatorBodies(),
+		// methods spawned by a method keep its access to private members
+		frames: []callFrame{e.currentFrame()},
+	}
+}
+
+// evalSpawn evaluates the arguments (and the receiver of a method call)
+// right away, so the task doesn't see later changes to the variables
+// they came from, and runs the call itself on a new goroutine.
+func (e *Evaluator) evalSpawn(stmt *parser.SpawnNode) any {
+	scope := env.NewEnv(e.currentEnv, "spawn")
+	var name string
+	var bound parser.Node
+
+	switch call := stmt.Call.(type) {
+	case *parser.FunctionCallNode:
+		args := e.bindSpawnArgs(scope, call.Args, call.Position)
+		if args == nil {
+			return nil
+		}
+		name = call.Name.String()
+		bound = &parser.FunctionCallNode{
+			Position: call.Position,
+			Name:     call.Name,
+			Args:     args,
+		}
+	case *parser.StructMethodCall:
+		caller := call.Caller
+		// 'self' and class names can't change while the task runs
+		if !e.isClassName(caller) && !isSelf(caller) {
+			self := e.EvalNode(caller)
+			if self == nil {
+				return nil
+			}
+			caller = spawnArg(scope, "self", self, call.Position)
+		}
+		args := e.bindSpawnArgs(scope, call.Args, call.Position)
+		if args == nil {
+			return nil
+		}
+		name = call.MethodName
+		bound = &parser.StructMethodCall{
+			Position:   call.Position,
+			Caller:     caller,
+			MethodName: call.MethodName,
+			Args:       args,
+		}
+	default:
+		e.GenError("'spawn' expects a function or method call", stmt.Position)
+		return nil
+	}
+
+	if !e.allowTask(stmt) {
+		return nil
+	}
+	return e.startTask(name, scope, bound)
+}
+
+// bindSpawnArgs evaluates call arguments into hidden variables of scope
+// and returns the arguments rewritten to refer to them.
+func (e *Evaluator) bindSpawnArgs(
+	scope *env.Env,
+	args []parser.Node,
+	pos parser.Position) []parser.Node {
+
+	bound := make([]parser.Node, len(args))
+	for i, arg := range args {
+		named, isNamed := arg.(*parser.NamedArgNode)
+		if isNamed {
+			arg = named.Value
+		}
+		value := e.EvalNode(arg)
+		if value == nil {
+			return nil
+		}
+		ident := spawnArg(scope, strconv.Itoa(i), value, pos)
+		if isNamed {
+			bound[i] = &parser.NamedArgNode{
+				Position: named.Position,
+				Name:     named.Name,
+				Value:    ident,
+			}
+			continue
+		}
+		bound[i] = ident
+	}
+	return bound
+}
+
+// spawnArg stores value in scope under a name no identifier can have.
+func spawnArg(scope *env.Env, key string, value any, pos parser.Position) *parser.IdentifierNode {
+	name := "spawn#" + key
+	scope.AddVarSymbol(name, "any", value)
+	return &parser.IdentifierNode{Position: pos, Name: name}
+}
+
+func (e *Evaluator) isClassName(node parser.Node) bool {
+	ident, ok := node.(*parser.IdentifierNode)
+	if !ok {
+		return false
+	}
+	_, ok = e.currentEnv.FindSymbol(ident.Name).(*env.StructSymbol)
+	return ok
+}
+
+func isSelf(node parser.Node) bool {
+	ident, ok := node.(*parser.IdentifierNode)
+	return ok && ident.Name == "self"
+}
+
+func (e *Evaluator) startTask(name string, scope *env.Env, call parser.Node) *task {
+	t := &task{name: name, done: make(chan struct{})}
+	worker := e.fork(scope)
+	e.tasks = append(e.tasks, t)
+
+	e.Environment.Share()
+	go func() {
+		defer close(t.done)
+		defer worker.finishTask()
+		result := worker.EvalNode(call)
+		// tasks spawned by the task belong to it
+		worker.waitTasks()
+		if len(worker.Errors) > 0 {
+			result = nil
+		}
+		t.result, t.errors = result, worker.Errors
+	}()
+	return t
+}
+
+// await blocks until t has finished and returns its result, or nil
+// after adding its errors to e.Errors if it failed.
+func (e *Evaluator) await(t *task) any {
+	select {
+	case <-t.done:
+	case <-e.interrupted():
+		return nil
+	}
+	t.mu.Lock()
+	if !t.reported {
+		t.reported = true
+		e.Errors = append(e.Errors, t.errors...)
+	}
+	t.mu.Unlock()
+	return t.result
+}
+
+// waitTasks waits for every task spawned by e that nothing has waited
+// for yet, so a program doesn't end while its tasks still run.
+func (e *Evaluator) waitTasks() bool {
+	ok := true
+	for _, t := range e.tasks {
+		if e.await(t) == nil {
+			ok = false
+		}
+	}
+	e.tasks = nil
+	return ok
+}
+
+func (t *task) String() string {
+	return fmt.Sprintf("<task %s>", t.name)
+}
+
+func (c *channel) String() string {
+	return "<channel>"
+}
+
+// join(task) waits for a task and returns the value its call returned.
+func builtinJoin(e *Evaluator, args []parser.Node, pos parser.Position) any {
+	if len(args) != 1 {
+		e.GenError("join: expects 1 argument", pos)
+		return nil
+	}
+	value := e.EvalNode(args[0])
+	if value == nil {
+		return nil
+	}
+	t, ok := value.(*task)
+	if !ok {
+		e.GenError(fmt.Sprintf(
+			"join: expects a task, got %s", e.resolveType(value, pos)), pos)
+		return nil
+	}
+	return e.await(t)
+}
+
+// wait(t1, t2, ...) or wait([t1, t2]) waits for the given tasks and
+// returns their results as an array, wait() waits for every task
+// spawned so far.
+func builtinWait(e *Evaluator, args []parser.Node, pos parser.Position) any {
+	if len(args) == 0 {
+		if !e.waitTasks() {
+			return nil
+		}
+		return core.NilValue{}
+	}
+
+	var tasks []any
+	for _, arg := range args {
+		value := e.EvalNode(arg)
+		if value == nil {
+			return nil
+		}
+		if isArray(value) {
+			for i := 0; i < arrayLen(value); i++ {
+				tasks = append(tasks, e.arrayGet(value, i))
+			}
+			continue
+		}
+		tasks = append(tasks, value)
+	}
+
+	results := make([]any, len(tasks))
+	failed := false
+	for i, value := range tasks {
+		t, ok := value.(*task)
+		if !ok {
+			e.GenError(fmt.Sprintf(
+				"wait: expects tasks, got %s", e.resolveType(value, pos)), pos)
+			return nil
+		}
+		if results[i] = e.await(t); results[i] == nil {
+			failed = true
+		}
+	}
+	if failed {
+		return nil
+	}
+	return results
+}
+
+// channel() makes an unbuffered channel, channel(n) one that holds up
+// to n values before 'send' blocks.
+func builtinChannel(e *Evaluator, args []parser.Node, pos parser.Position) any {
+	if len(args) > 1 {
+		e.GenError("channel: expects at most 1 argument", pos)
+		return nil
+	}
+	size := 0
+	if len(args) == 1 {
+		value := e.EvalNode(args[0])
+		if value == nil {
+			return nil
+		}
+		n, ok := unwrapBuiltinValue(value).(int)
+		if !ok || n < 0 {
+			e.GenError("channel: size must be a non negative int", pos)
+			return nil
+		}
+		size = n
+	}
+	return &channel{ch: make(chan any, size)}
+}
+
+// evalChannelArgs evaluates the arguments of send, recv and close, the
+// first of which has to be a channel.
+func (e *Evaluator) evalChannelArgs(
+	name string,
+	count int,
+	args []parser.Node,
+	pos parser.Position) (*channel, []any) {
+
+	if len(args) != count {
+		e.GenError(fmt.Sprintf("%s: expects %d arguments", name, count), pos)
+		return nil, nil
+	}
+	values := make([]any, count)
+	for i, arg := range args {
+		if values[i] = e.EvalNode(arg); values[i] == nil {
+			return nil, nil
+		}
+	}
+	ch, ok := values[0].(*channel)
+	if !ok {
+		e.GenError(fmt.Sprintf("%s: expects a channel, got %s",
+			name, e.resolveType(values[0], pos)), pos)
+		return nil, nil
+	}
+	return ch, values[1:]
+}
+
+// send(ch, value) blocks until the value is received or buffered.
+func builtinSend(e *Evaluator, args []parser.Node, pos parser.Position) (result any) {
+	ch, values := e.evalChannelArgs("send", 2, args, pos)
+	if ch == nil {
+		return nil
+	}
+	defer func() {
+		if recover() != nil {
+			e.GenError("send: channel is closed", pos)
+			result = nil
+		}
+	}()
+	select {
+	case ch.ch <- values[0]:
+		return core.NilValue{}
+	case <-e.interrupted():
+		return nil
+	}
+}
+
+// recv(ch) blocks until a value is sent, it returns nil once ch is
+// closed and empty.
+func builtinRecv(e *Evaluator, args []parser.Node, pos parser.Position) any {
+	ch, _ := e.evalChannelArgs("recv", 1, args, pos)
+	if ch == nil {
+		return nil
+	}
+	value, ok := e.receive(ch)
+	if !ok {
+		if e.halted() {
+			return nil
+		}
+		return core.NilValue{}
+	}
+	return value
+}
+
+// receive waits for a value from c, ok is false once c is closed and
+// empty or the program was stopped.
+func (e *Evaluator) receive(c *channel) (value any, ok bool) {
+	select {
+	case value, ok = <-c.ch:
+		return value, ok
+	case <-e.interrupted():
+		return nil, false
+	}
+}
+
+// close(ch) ends 'for x in ch' loops once the buffered values are read.
+func builtinClose(e *Evaluator, args []parser.Node, pos parser.Position) (result any) {
+	ch, _ := e.evalChannelArgs("close", 1, args, pos)
+	if ch == nil {
+		return nil
+	}
+	defer func() {
+		if recover() != nil {
+			e.GenError("close: channel is already closed", pos)
+			result = nil
+		}
+	}()
+	close(ch.ch)
+	return core.NilValue{}
+}
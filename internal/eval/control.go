@@ -1,27 +1,60 @@
 package eval
 
 import (
+	"fmt"
 	"lang/internal/core"
 	"lang/internal/env"
 	"lang/internal/parser"
 )
 
 func (e *Evaluator) evalBlock(block *parser.BlockNode) any {
-	blockEnv := env.NewEnv(e.currentEnv, "block")
 	prevEnv := e.currentEnv
+	blockEnv := e.pushEnv(prevEnv, "block")
 	e.currentEnv = blockEnv
-	var result any
+	var result any = core.NilValue{}
 	for _, stmt := range block.Statements {
 		result = e.EvalNode(stmt)
-		if _, ok := result.(core.ReturnValue); ok || result != nil {
-			e.currentEnv = prevEnv
+		switch result.(type) {
+		case nil, core.ReturnValue, core.BreakSignal, core.ContinueSignal:
+			e.popEnv(block, blockEnv, prevEnv)
 			return result
 		}
 	}
-	e.currentEnv = prevEnv
+	e.popEnv(block, blockEnv, prevEnv)
 	return result
 }
 
+// pushEnv returns an empty env, reusing one released by popEnv when it
+// can, so loop bodies and calls don't allocate a scope every time.
+func (e *Evaluator) pushEnv(parent *env.Env, envType string) *env.Env {
+	if n := len(e.spareEnvs); n > 0 {
+		scope := e.spareEnvs[n-1]
+		e.spareEnvs = e.spareEnvs[:n-1]
+		scope.Reset(parent, envType)
+		return scope
+	}
+	return env.NewEnv(parent, envType)
+}
+
+// popEnv leaves the env of block, keeping it for reuse if the resolver
+// found nothing in the block that holds on to it.
+func (e *Evaluator) popEnv(block *parser.BlockNode, scope, prevEnv *env.Env) {
+	e.currentEnv = prevEnv
+	if block.Reusable {
+		e.releaseEnv(scope)
+	}
+}
+
+// maxSpareEnvs bounds the envs kept after deep recursion.
+const maxSpareEnvs = 64
+
+func (e *Evaluator) releaseEnv(scope *env.Env) {
+	if len(e.spareEnvs) < maxSpareEnvs {
+		scope.Reset(nil, "")
+		e.spareEnvs = append(e.spareEnvs, scope)
+	}
+}
+
 func (e *Evaluator) evalIf(stmt *parser.IfNode) any {
 	cond := e.evalCondition(stmt.Condition)
 	if cond == nil {
@@ -41,12 +74,11 @@ func (e *Evaluator) evalIf(stmt *parser.IfNode) any {
 		}
 		return res
 	} else {
-		return nil
+		return core.NilValue{}
 	}
 }
 
 func (e *Evaluator) evalWhile(stmt *parser.WhileNode) any {
-	var result any
 	for {
 		cond := e.EvalNode(stmt.Condition)
 		b, ok := cond.(bool)
@@ -59,19 +91,14 @@ func (e *Evaluator) evalWhile(stmt *parser.WhileNode) any {
 			break
 		}
 		bodyResult := e.evalLoopBlock(stmt.Body)
-		if _, isBreak := bodyResult.(core.BreakSignal); isBreak {
-			break
-		}
-		if ret, isReturn := bodyResult.(core.ReturnValue); isReturn {
-			return ret
+		if exit, res := loopExit(stmt.Label, bodyResult); exit {
+			return res
 		}
-		result = bodyResult
 	}
-	return result
+	return core.NilValue{}
 }
 
 func (e *Evaluator) evalFor(stmt *parser.ForNode) any {
-	var result any
 	switch v := stmt.Init.(type) {
 	case *parser.VarDefNode:
 		{
@@ -102,43 +129,148 @@ func (e *Evaluator) evalFor(stmt *parser.ForNode) any {
 			break
 		}
 		bodyResult := e.evalLoopBlock(stmt.Body)
-		if bodyResult == nil {
-			return nil
+		if exit, res := loopExit(stmt.Label, bodyResult); exit {
+			return res
 		}
-		if _, isBreak := bodyResult.(core.BreakSignal); isBreak {
-			break
+		e.EvalNode(stmt.Post)
+	}
+	return core.NilValue{}
+}
+
+// evalForIn runs 'for (name in iterable)'. The loop variable lives in
+// its own env, so it doesn't leak into the enclosing scope.
+func (e *Evaluator) evalForIn(stmt *parser.ForInNode) any {
+	iterable := e.EvalNode(stmt.Iterable)
+	if iterable == nil {
+		return nil
+	}
+
+	prevEnv := e.currentEnv
+	loopEnv := env.NewEnv(e.currentEnv, "loop")
+	e.currentEnv = loopEnv
+	defer func() { e.currentEnv = prevEnv }()
+
+	var result any = core.NilValue{}
+	ok := e.forEachItem(iterable, stmt.Position, func(item any) bool {
+		if stmt.Pattern != nil {
+			if !e.destructure(loopEnv, stmt.Pattern, item, stmt.Position) {
+				result = nil
+				return false
+			}
+		} else {
+			loopEnv.AddVarSymbol(stmt.Var, e.resolveType(item, stmt.Position), item)
 		}
-		if ret, isRet := bodyResult.(core.ReturnValue); isRet {
-			return ret
+		exit, res := loopExit(stmt.Label, e.evalLoopBlock(stmt.Body))
+		if exit {
+			result = res
 		}
-		result = bodyResult
-		e.EvalNode(stmt.Post)
+		return !exit
+	})
+	if !ok {
+		return nil
 	}
 	return result
 }
 
+// evalDoWhile runs the body once before checking the condition.
+func (e *Evaluator) evalDoWhile(stmt *parser.DoWhileNode) any {
+	for {
+		bodyResult := e.evalLoopBlock(stmt.Body)
+		if exit, res := loopExit(stmt.Label, bodyResult); exit {
+			return res
+		}
+		cond := e.EvalNode(stmt.Condition)
+		b, ok := unwrapBuiltinValue(cond).(bool)
+		if !ok {
+			e.GenError("Do-while loop condition should return bool",
+				stmt.Position)
+			return nil
+		}
+		if !b {
+			return core.NilValue{}
+		}
+	}
+}
+
 func (e *Evaluator) evalReturn(ret *parser.ReturnNode) any {
+	if ret.Value == nil {
+		// bare 'return;'
+		return core.ReturnValue{Value: core.NilValue{}}
+	}
+	if e.canTailCall() {
+		e.tailCall = ret.Value
+	}
 	val := e.EvalNode(ret.Value)
+	e.tailCall = nil
 	return core.ReturnValue{Value: val}
 }
 
 func (e *Evaluator) evalLoopBlock(block *parser.BlockNode) any {
-	blockEnv := env.NewEnv(e.currentEnv, "block")
 	prevEnv := e.currentEnv
+	blockEnv := e.pushEnv(prevEnv, "block")
 	e.currentEnv = blockEnv
 
 	var result any = core.NilValue{}
 	for _, stmt := range block.Statements {
-		result := e.EvalNode(stmt)
-		if _, ok := stmt.(*parser.BreakNode); ok {
-			e.currentEnv = prevEnv
-			return core.BreakSignal{}
-		}
-		if ret, ok := result.(core.ReturnValue); ok {
-			e.currentEnv = prevEnv
-			return ret
+		res := e.EvalNode(stmt)
+		switch res.(type) {
+		case nil, core.ReturnValue, core.BreakSignal, core.ContinueSignal:
+			e.popEnv(block, blockEnv, prevEnv)
+			return res
 		}
 	}
-	e.currentEnv = prevEnv
+	e.popEnv(block, blockEnv, prevEnv)
 	return result
 }
+
+// loopExit decides what a loop does with the result of one iteration.
+// It exits on errors, returns and on break, continue meant for an outer
+// loop. res is what the loop then evaluates to.
+func loopExit(label string, bodyResult any) (exit bool, res any) {
+	switch sig := bodyResult.(type) {
+	case nil, core.ReturnValue:
+		return true, sig
+	case core.BreakSignal:
+		if sig.Label == "" || sig.Label == label {
+			return true, core.NilValue{}
+		}
+		return true, sig
+	case core.ContinueSignal:
+		if sig.Label == "" || sig.Label == label {
+			return false, nil
+		}
+		return true, sig
+	}
+	return false, nil
+}
+
+func (e *Evaluator) evalBreak(stmt *parser.BreakNode) any {
+	return core.BreakSignal{Label: stmt.Label, Pos: stmt.Position}
+}
+
+func (e *Evaluator) evalContinue(stmt *parser.ContinueNode) any {
+	return core.ContinueSignal{Label: stmt.Label, Pos: stmt.Position}
+}
+
+// checkLoopSignal reports a break or continue that left every loop,
+// which happens when it is used outside of one or names a missing label.
+func (e *Evaluator) checkLoopSignal(result any) bool {
+	var keyword, label string
+	var pos parser.Position
+	switch sig := result.(type) {
+	case core.BreakSignal:
+		keyword, label, pos = "break", sig.Label, sig.Pos
+	case core.ContinueSignal:
+		keyword, label, pos = "continue", sig.Label, sig.Pos
+	default:
+		return true
+	}
+	if label != "" {
+		e.GenError(fmt.Sprintf(
+			"'%s %s': no enclosing loop is labeled '%s'",
+			keyword, label, label), pos)
+	} else {
+		e.GenError(fmt.Sprintf("'%s' outside of a loop", keyword), pos)
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"lang/internal/core"
+	"lang/internal/env"
+	"lang/internal/parser"
+	"math"
+	"reflect"
+)
+
+// ToValue converts a Go value for the program. nil, bools, numbers and
+// strings become nil, bool, int, float and string, slices become
+// arrays and structs of registered classes new instances. Values that
+// came from FromValue without being converted, like tasks or
+// generators, are passed back as they are.
+func (e *Evaluator) ToValue(v any) (any, error) {
+	switch v := v.(type) {
+	case nil:
+		return core.NilValue{}, nil
+	case bool, int, float64:
+		return v, nil
+	case string:
+		return e.createString(v), nil
+	case []int:
+		return append([]int{}, v...), nil
+	case []float64:
+		return append([]float64{}, v...), nil
+	case core.NilValue, core.Range, *env.Env, *generator, *task, *channel:
+		return v, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if c := e.hostClassOf(rv.Type()); c != nil {
+		return e.hostValue(c, rv)
+	}
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n := rv.Uint()
+		if n > math.MaxInt {
+			return nil, fmt.Errorf("%d overflows int", n)
+		}
+		return int(n), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.String:
+		return e.createString(rv.String()), nil
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.Slice, reflect.Array:
+		values := make([]any, rv.Len())
+		for i := range values {
+			value, err := e.ToValue(rv.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("cannot convert %T to a value", v)
+}
+
+// FromValue converts a value of the program for Go. nil, bools, numbers
+// and strings become nil, bool, int, float64 and string, arrays become
+// []any, []int or []float64 and ranges []int. Instances become a map of
+// their public fields and enum members their printed name. Other
+// values, like tasks or generators, are returned as they are.
+func (e *Evaluator) FromValue(v any) any {
+	return e.fromValue(v, make(map[*env.Env]map[string]any))
+}
+
+// fromValue converts v, seen holds the instances converted so far so
+// instances that refer to themselves become maps that do.
+func (e *Evaluator) fromValue(v any, seen map[*env.Env]map[string]any) any {
+	switch v := unwrapBuiltinValue(v).(type) {
+	case core.NilValue:
+		return nil
+	case []any:
+		values := make([]any, len(v))
+		for i, item := range v {
+			values[i] = e.fromValue(item, seen)
+		}
+		return values
+	case []int:
+		return append([]int{}, v...)
+	case []float64:
+		return append([]float64{}, v...)
+	case core.Range:
+		values := make([]int, v.Len())
+		for i := range values {
+			values[i] = v.At(i)
+		}
+		return values
+	case *env.Env:
+		// instances and enum members have the type of the env they're in
+		class := v.Parent
+		if class == nil || class.Type != v.Type {
+			return v
+		}
+		if class.Members != nil {
+			return e.FormatValue(v)
+		}
+		if fields, ok := seen[v]; ok {
+			return fields
+		}
+		fields := make(map[string]any)
+		seen[v] = fields
+		for _, name := range class.Fields {
+			if sym, ok := v.Get(name); ok && !class.IsPrivate(name) {
+				fields[name] = e.fromValue(sym.Value(), seen)
+			}
+		}
+		return fields
+	default:
+		return v
+	}
+}
+
+// Global returns the value of the global variable name converted by
+// FromValue, ok is false if there is no such variable.
+func (e *Evaluator) Global(name string) (value any, ok bool) {
+	sym, ok := e.Environment.Symbol(name).(*env.VarSymbol)
+	if !ok {
+		return nil, false
+	}
+	return e.FromValue(sym.Value()), true
+}
+
+// SetGlobal converts value by ToValue and assigns it to the global
+// variable name, which is declared if it doesn't exist yet. The value
+// has to match the type the variable was declared with.
+func (e *Evaluator) SetGlobal(name string, value any) error {
+	v, err := e.ToValue(value)
+	if err != nil {
+		return err
+	}
+	pos := parser.Position{}
+	switch sym := e.Environment.Symbol(name).(type) {
+	case nil:
+		e.Environment.AddVarSymbol(name, e.resolveType(v, pos), v)
+	case *env.VarSymbol:
+		errs := len(e.Errors)
+		v, ok := e.checkType(sym.Declared(), v, fmt.Sprintf("variable '%s'", name), pos)
+		if !ok {
+			err := errors.Join(e.Errors[errs:]...)
+			e.Errors = e.Errors[:errs]
+			return err
+		}
+		e.Environment.UpdateSymbol(name, v, e.resolveType(v, pos))
+	default:
+		return fmt.Errorf("'%s' is not a variable", name)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package eval
+
+import (
+	"fmt"
+	"lang/internal/env"
+	"lang/internal/parser"
+)
+
+// destructure defines the names of pat in target, reading them from an
+// array by position or from an instance by field.
+func (e *Evaluator) destructure(
+	target *env.Env,
+	pat *parser.Destructure,
+	value any,
+	pos parser.Position) bool {
+
+	values, ok := e.unpack(pat, unwrapBuiltinValue(value), pos)
+	if !ok {
+		return false
+	}
+	names := pat.Names
+	if pat.Rest != "" {
+		names = append(names[:len(names):len(names)], pat.Rest)
+	}
+	for i, name := range names {
+		if name == "_" {
+			continue
+		}
+		target.AddVarSymbol(name, e.resolveType(values[i], pos), values[i])
+	}
+	return true
+}
+
+// unpack returns the values for the names of pat, followed by the rest
+// array if the pattern has one.
+func (e *Evaluator) unpack(
+	pat *parser.Destructure,
+	value any,
+	pos parser.Position) ([]any, bool) {
+
+	if pat.ByField() {
+		inst, ok := value.(*env.Env)
+		if !ok || inst.Parent == nil {
+			e.GenError(fmt.Sprintf(
+				"Cannot destructure %s by field, expected an instance",
+				e.typeName(value)), pos)
+			return nil, false
+		}
+		values := make([]any, len(pat.Fields))
+		for i, field := range pat.Fields {
+			if !e.checkMemberAccess(inst, inst.Parent, field, pos) {
+				return nil, false
+			}
+			sym, ok := inst.Get(field)
+			if !ok && inst.Parent.IsStatic(field) {
+				sym, ok = inst.Parent.Get(field)
+			}
+			if _, isVar := sym.(*env.VarSymbol); !ok || !isVar {
+				e.GenError(fmt.Sprintf(
+					"'%s' has no field '%s'", inst.Type, field), pos)
+				return nil, false
+			}
+			values[i] = sym.Value()
+		}
+		return values, true
+	}
+
+	if !isArray(value) {
+		e.GenError(fmt.Sprintf(
+			"Cannot destructure %s, expected an array", e.typeName(value)), pos)
+		return nil, false
+	}
+	n := arrayLen(value)
+	switch {
+	case n < len(pat.Names):
+		e.GenError(fmt.Sprintf(
+			"Not enough values to destructure: expected %d, got %d",
+			len(pat.Names), n), pos)
+		return nil, false
+	case n > len(pat.Names) && pat.Rest == "":
+		e.GenError(fmt.Sprintf(
+			"Too many values to destructure: expected %d, got %d",
+			len(pat.Names), n), pos)
+		return nil, false
+	}
+	values := make([]any, 0, len(pat.Names)+1)
+	for i := range pat.Names {
+		values = append(values, e.arrayGet(value, i))
+	}
+	if pat.Rest != "" {
+		rest := []any{}
+		for i := len(pat.Names); i < n; i++ {
+			rest = append(rest, e.arrayGet(value, i))
+		}
+		values = append(values, rest)
+	}
+	return values, true
+}
@@ -0,0 +1,151 @@
+package eval
+
+import (
+	"fmt"
+	"lang/internal/core"
+	"lang/internal/env"
+	"lang/internal/parser"
+)
+
+// evalEnumDef declares an enum as a class whose members are static
+// instances holding their 'name' and 'ordinal'.
+func (e *Evaluator) evalEnumDef(stmt *parser.EnumDefNode) any {
+	if e.currentEnv.SymbolExists(stmt.Name) {
+		e.GenError(fmt.Sprintf(
+			"Enum '%s' already exists", stmt.Name), stmt.Position)
+		return nil
+	}
+	enumEnv := env.NewEnv(e.currentEnv, stmt.Name)
+
+	for i, name := range stmt.Members {
+		if _, ok := enumMethods[name]; ok || isEnumStaticMethod(name) {
+			e.GenError(fmt.Sprintf(
+				"'%s' is reserved and cannot be an enum member", name),
+				stmt.Position)
+			return nil
+		}
+		member := env.NewEnv(enumEnv, stmt.Name)
+		member.AddVarSymbol("name", "string", e.createString(name))
+		member.AddVarSymbol("ordinal", "int", i)
+		enumEnv.Members = append(enumEnv.Members, member)
+
+		enumEnv.AddVarSymbol(name, stmt.Name, member)
+		enumEnv.SetStatic(name)
+	}
+
+	for name, fn := range enumMethods {
+		enumEnv.Set(name, &env.FuncSymbol{
+			NativeFunc: fn,
+			TypeName:   stmt.Name,
+		})
+	}
+	for name, fn := range enumStaticMethods(enumEnv) {
+		enumEnv.Set(name, &env.FuncSymbol{
+			NativeFunc: fn,
+			TypeName:   stmt.Name,
+		})
+		enumEnv.SetStatic(name)
+	}
+
+	e.currentEnv.AddStructSymbol(stmt.Name, enumEnv)
+
+	return enumEnv
+}
+
+type nativeMethod = func(core.Evaluator, *env.Env, []any, parser.Position) any
+
+var enumMethods = map[string]nativeMethod{
+	"name":     enumName,
+	"toString": enumName,
+	"ordinal":  enumOrdinal,
+	"toInt":    enumOrdinal,
+}
+
+func enumName(e core.Evaluator, self *env.Env, args []any, pos parser.Position) any {
+	if len(args) != 0 {
+		e.GenError("name: expects no arguments", pos)
+		return nil
+	}
+	return self.Symbol("name").Value()
+}
+
+func enumOrdinal(e core.Evaluator, self *env.Env, args []any, pos parser.Position) any {
+	if len(args) != 0 {
+		e.GenError("ordinal: expects no arguments", pos)
+		return nil
+	}
+	return self.Symbol("ordinal").Value()
+}
+
+// enumStaticMethods builds 'values', 'fromString' and 'fromInt' for
+// enumEnv, static methods have no self to find their enum through.
+func enumStaticMethods(enumEnv *env.Env) map[string]nativeMethod {
+	return map[string]nativeMethod{
+		"values": func(e core.Evaluator, _ *env.Env, args []any, pos parser.Position) any {
+			if len(args) != 0 {
+				e.GenError("values: expects no arguments", pos)
+				return nil
+			}
+			values := make([]any, len(enumEnv.Members))
+			for i, member := range enumEnv.Members {
+				values[i] = member
+			}
+			return values
+		},
+		"fromString": func(e core.Evaluator, _ *env.Env, args []any, pos parser.Position) any {
+			if len(args) != 1 {
+				e.GenError("fromString: expects one argument", pos)
+				return nil
+			}
+			name, ok := unwrapBuiltinValue(args[0]).(string)
+			if !ok {
+				e.GenError("fromString: argument must be a string", pos)
+				return nil
+			}
+			for _, member := range enumEnv.Members {
+				if unwrapBuiltinValue(member.Symbol("name").Value()) == name {
+					return member
+				}
+			}
+			e.GenError(fmt.Sprintf(
+				"'%s' is not a member of enum '%s'", name, enumEnv.Type), pos)
+			return nil
+		},
+		"fromInt": func(e core.Evaluator, _ *env.Env, args []any, pos parser.Position) any {
+			if len(args) != 1 {
+				e.GenError("fromInt: expects one argument", pos)
+				return nil
+			}
+			i, ok := unwrapBuiltinValue(args[0]).(int)
+			if !ok {
+				e.GenError("fromInt: argument must be an int", pos)
+				return nil
+			}
+			if i < 0 || i >= len(enumEnv.Members) {
+				e.GenError(fmt.Sprintf(
+					"%d is not an ordinal of enum '%s'", i, enumEnv.Type), pos)
+				return nil
+			}
+			return enumEnv.Members[i]
+		},
+	}
+}
+
+func isEnumStaticMethod(name string) bool {
+	return name == "values" || name == "fromString" || name == "fromInt"
+}
+
+// enumOf returns the enum env v belongs to, or nil if v is neither an
+// enum nor one of its members.
+func enumOf(v *env.Env) *env.Env {
+	if v == nil {
+		return nil
+	}
+	if v.Members != nil {
+		return v
+	}
+	if v.Parent != nil && v.Parent.Members != nil {
+		return v.Parent
+	}
+	return nil
+}
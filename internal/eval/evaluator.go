@@ -1,10 +1,14 @@
 package eval
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"lang/internal/env"
 	"lang/internal/lexer"
 	"lang/internal/parser"
+	"lang/internal/resolver"
+	"sync"
 )
 
 type Evaluator struct {
@@ -15,6 +19,43 @@ type Evaluator struct {
 	parser *parser.Parser
 	lexer *lexer.Lexer
 	Builtins map[string]BuiltinFunction
+	// Last holds the value of the last evaluated top-level statement
+	Last any
+	// the generator body the evaluator runs
+	generator *genBody
+	// generator bodies that haven't finished, see StopGenerators
+	bodies *genBodies
+	// tasks spawned by this evaluator that nothing has waited for yet
+	tasks []*task
+	// timers scheduled by setTimeout and setInterval
+	loop *eventLoop
+	// envs of finished blocks and calls, reused by pushEnv
+	spareEnvs []*env.Env
+	// MaxCallDepth limits nested calls, DefaultMaxCallDepth when 0
+	MaxCallDepth int
+	// calls in progress, innermost last
+	frames []callFrame
+	// the value of the 'return' being evaluated, see isTailCall
+	tailCall parser.Node
+	// Sandbox limits what the program may do, nil allows everything
+	Sandbox *Sandbox
+	// the sandbox while the program runs
+	limits *limits
+	// stops the program once its context is done or a limit is broken
+	interrupt *interrupt
+	// modules and classes registered by the embedding program
+	host *host
+	// Stdin, Stdout and Stderr are used by input, print and diagnostics
+	// instead of the os ones when set
+	Stdin io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// serializes output, see outputLock
+	outMu *sync.Mutex
+	// guard array elements shared by tasks, see arrayLock
+	arrays *arrayLocks
+	// reads Stdin for input, see inputs
+	in *inputReader
 }
 
 func NewEvaluatorAutoEnv(entry *parser.ProgramNode) *Evaluator {
@@ -23,6 +64,7 @@ func NewEvaluatorAutoEnv(entry *parser.ProgramNode) *Evaluator {
 		Environment: env,
 		Entry: entry,
 		currentEnv: env,
+		loop: newEventLoop(),
 	}
 
 	evaluator.initBuiltintClasses()
@@ -37,6 +79,7 @@ func NewEvaluator(env *env.Env, entry *parser.ProgramNode) *Evaluator {
 		Environment: env,
 		Entry: entry,
 		currentEnv: env,
+		loop: newEventLoop(),
 	}
 
 	evaluator.initBuiltintClasses()
@@ -47,16 +90,53 @@ func NewEvaluator(env *env.Env, entry *parser.ProgramNode) *Evaluator {
 }
 
 func (e *Evaluator) Eval() {
+	e.EvalContext(context.Background())
+}
+
+// EvalContext is Eval stopping once ctx is done. It returns the error
+// the program was stopped with, by ctx or a sandbox limit, which is
+// also added to Errors. Only a cancelled one wraps ErrCancelled.
+func (e *Evaluator) EvalContext(ctx context.Context) error {
+	if !e.begin(ctx) {
+		return nil
+	}
+	e.evalProgram()
+	return e.end()
+}
+
+func (e *Evaluator) evalProgram() {
+	e.currentEnv = e.Environment
+	defer e.waitTasks()
+	if errs := e.Resolve(); len(errs) > 0 {
+		e.Errors = append(e.Errors, errs...)
+		return
+	}
 	for _, stmt := range e.Entry.Nodes{
-		if e.EvalNode(stmt) == nil {
+		e.Last = e.EvalNode(stmt)
+		if e.Last == nil || !e.checkLoopSignal(e.Last) {
 			return
 		}
 	}
+	if !e.runEventLoop() {
+		return
+	}
 
 	e.Environment = e.currentEnv
 }
 
+// Resolve prepares the program for evaluation and returns the scope
+// errors found in it without running it.
+func (e *Evaluator) Resolve() []error {
+	return resolver.Resolve(e.Entry, func(name string) bool {
+		_, ok := e.Builtins[name]
+		return ok || e.Environment.SymbolExists(name)
+	})
+}
+
 func (e *Evaluator) EvalNode(stmt parser.Node) any {
+	if e.interrupt != nil && !e.step(stmt) {
+		return nil
+	}
     // fmt.Printf("eval node type: %T\n", stmt)
     res := e.evalX(stmt)  // actual dispatch
     // if _, ok := res.(core.ReturnValue); ok {
@@ -79,6 +159,12 @@ func (e *Evaluator) evalX(stmt parser.Node) any {
 		return e.evalIdentifier(s)
 	case *parser.FunctionCallNode: 
 		return e.evalFunctionCall(s)
+	case *parser.NamedArgNode:
+		return e.evalNamedArg(s)
+	case *parser.YieldNode:
+		return e.evalYield(s)
+	case *parser.SpawnNode:
+		return e.evalSpawn(s)
 	case *parser.LiteralNode: 
 		return e.evalLiteral(s)
 	case *parser.TrueNode:
@@ -109,6 +195,20 @@ func (e *Evaluator) evalX(stmt parser.Node) any {
 		return e.evalImport(s)
 	case *parser.StructDefNode:
 		return e.evalStructDef(s)
+	case *parser.ForInNode:
+		return e.evalForIn(s)
+	case *parser.DoWhileNode:
+		return e.evalDoWhile(s)
+	case *parser.RangeNode:
+		return e.evalRange(s)
+	case *parser.BreakNode:
+		return e.evalBreak(s)
+	case *parser.ContinueNode:
+		return e.evalContinue(s)
+	case *parser.MatchNode:
+		return e.evalMatch(s)
+	case *parser.EnumDefNode:
+		return e.evalEnumDef(s)
 	case *parser.StructMethodDef:
 		return e.evalStructMethodDef(s)
 	case *parser.StructInitNode:
@@ -117,6 +217,8 @@ func (e *Evaluator) evalX(stmt parser.Node) any {
 		return e.evalStructMemberAccess(s)
 	case *parser.NilNode:
 		return e.evalNil(s)
+	case *parser.ExpressionStatementNode:
+		return e.EvalNode(s.Expr)
 	default: {
 		e.GenError(fmt.Sprintf(
 			"Unknown node type: %T", s), parser.Position{Row: -1, Column: -1})
@@ -139,29 +241,29 @@ func (e *Evaluator) createString(value string) *env.Env {
 func (e *Evaluator) initStringBuiltin() {
 	stringSymbol := e.Environment.FindStructSymbol("string")
 	if stringSymbol != nil {
-		stringSymbol.Symbols["substring"] = &env.FuncSymbol{
+		stringSymbol.Set("substring", &env.FuncSymbol{
 			NativeFunc: stringSubstring,
 			TypeName:   "string",
-		}
-		stringSymbol.Symbols["capitalize"] = &env.FuncSymbol{
+		})
+		stringSymbol.Set("capitalize", &env.FuncSymbol{
 			NativeFunc: stringCapitalize,
 			TypeName:   "string",
-		}
-		stringSymbol.Symbols["contains"] = &env.FuncSymbol{
+		})
+		stringSymbol.Set("contains", &env.FuncSymbol{
 			NativeFunc: stringContains,
 			TypeName:   "string",
-		}
-		stringSymbol.Symbols["empty"] = &env.FuncSymbol{
+		})
+		stringSymbol.Set("empty", &env.FuncSymbol{
 			NativeFunc: stringEmpty,
 			TypeName:   "string",
-		}
-		stringSymbol.Symbols["isDigit"] = &env.FuncSymbol{
+		})
+		stringSymbol.Set("isDigit", &env.FuncSymbol{
 			NativeFunc: stringIsDigit,
 			TypeName:   "string",
-		}
-		stringSymbol.Symbols["isAlph"] = &env.FuncSymbol{
+		})
+		stringSymbol.Set("isAlph", &env.FuncSymbol{
 			NativeFunc: stringIsAlph,
 			TypeName:   "string",
-		}
+		})
 	}
 }
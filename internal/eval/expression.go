@@ -7,6 +7,11 @@ import (
 )
 
 func (e *Evaluator) evalBinary(expr *parser.BinaryOpNode) any {
+	if expr.Op == "&&" || expr.Op == "||" {
+		return e.evalLogical(expr)
+	}
+
+	errs := len(e.Errors)
 	left := unwrapBuiltinValue(e.EvalNode(expr.Left))
 	if ret, ok := left.(core.ReturnValue); ok {
 		left = ret.Value
@@ -16,10 +21,17 @@ func (e *Evaluator) evalBinary(expr *parser.BinaryOpNode) any {
 		right = ret.Value
 	}
 	if (left == nil || right == nil) {
-		e.GenError("Expression operand cannot bet nil", expr.Position)
+		// the operand already reported why it failed
+		if len(e.Errors) == errs {
+			e.GenError("Expression operand cannot bet nil", expr.Position)
+		}
 		return nil
 	}
 
+	if result, ok := e.evalBinaryOverload(expr.Op, left, right, expr.Position); ok {
+		return result
+	}
+
     switch expr.Op {
     case "+":
         switch l := left.(type) {
@@ -55,6 +67,9 @@ func (e *Evaluator) evalBinary(expr *parser.BinaryOpNode) any {
                     expr.Position)
                 return nil
             }
+            if !e.reserve(uint64(len(l)+len(r)), expr.Position) {
+                return nil
+            }
             return e.createString(l + r)
         default:
             e.GenError("Unsupported type for '+' operator",
@@ -127,7 +142,7 @@ func (e *Evaluator) evalBinary(expr *parser.BinaryOpNode) any {
             }
         }
     default:
-        return e.evalLogical(expr)
+        return e.evalComparison(expr, left, right)
     }
     e.GenError("Unknown", expr.Position)
     return nil
@@ -139,6 +154,9 @@ func (e *Evaluator) evalUnary(node *parser.UnaryOpNode) any {
         e.GenError("Value with unary shouldn't be nil!", node.Position)
         return nil
     }
+    if result, ok := e.evalUnaryOverload(node.Op, value, node.Position); ok {
+        return result
+    }
     switch node.Op {
     case "++", "--":
         if node.Op == "++" {
@@ -265,14 +283,13 @@ func (e *Evaluator) evalLogical(node *parser.BinaryOpNode) any {
 		return rBool
 	}
 	}
-	left := unwrapBuiltinValue(e.EvalNode(node.Left))
-	right := unwrapBuiltinValue(e.EvalNode(node.Right))
-
-	if (left == nil || right == nil) {
-		e.GenError("Failed to get value", node.Position)
-		return nil
-	}
+	e.GenError(fmt.Sprintf(
+		"Unknown operator: %s", node.Op),
+		node.Position)
+	return nil
+}
 
+func (e *Evaluator) evalComparison(node *parser.BinaryOpNode, left, right any) any {
 	switch node.Op {
 	case ">", "<", ">=", "<=":
 		if lStr, lok := left.(string); lok {
@@ -326,10 +343,9 @@ func (e *Evaluator) evalLogical(node *parser.BinaryOpNode) any {
 		// }
 		switch node.Op {
 		case "==":
-			// Allow nil == nil, and nil == any other is false
-			return isNilValue(left) && isNilValue(right) || left == right
+			return e.valuesEqual(left, right, node.Position)
 		case "!=":
-			return !isNilValue(left) && isNilValue(right) || isNilValue(left) && !isNilValue(right) || left != right
+			return !e.valuesEqual(left, right, node.Position)
 		}
 	default:
 		e.GenError(fmt.Sprintf(
@@ -350,7 +366,12 @@ func (e *Evaluator) handlePostfixPP(node *parser.UnaryOpNode) any {
 		return nil
 	}
 
-	val := e.currentEnv.FindSymbol(ident.Name)
+	val, owner := e.lookup(ident)
+	if val == nil {
+		e.GenError(fmt.Sprintf("Variable '%s' does not exist", ident.Name),
+			node.Position)
+		return nil
+	}
 	intVal, ok := val.Value().(int)
 	if !ok {
 		e.GenError("++ operator requires integer value",
@@ -360,7 +381,7 @@ func (e *Evaluator) handlePostfixPP(node *parser.UnaryOpNode) any {
 
 	origVal := val
 
-	e.currentEnv.UpdateSymbol(ident.Name, intVal+1, "int")
+	owner.UpdateInCurrent(ident.Name, intVal+1, "int")
 
 	return origVal
 }
@@ -373,7 +394,12 @@ func (e *Evaluator) handlePostfixMM(node *parser.UnaryOpNode) any {
 		return nil
 	}
 
-	val := e.currentEnv.FindSymbol(ident.Name)
+	val, owner := e.lookup(ident)
+	if val == nil {
+		e.GenError(fmt.Sprintf("Variable '%s' does not exist", ident.Name),
+			node.Position)
+		return nil
+	}
 	intVal, ok := val.Value().(int)
 	if !ok {
 		e.GenError("-- operator requires integer value",
@@ -383,7 +409,7 @@ func (e *Evaluator) handlePostfixMM(node *parser.UnaryOpNode) any {
 
 	origVal := val
 
-	e.currentEnv.UpdateSymbol(ident.Name, intVal-1, "int")
+	owner.UpdateInCurrent(ident.Name, intVal-1, "int")
 
 	return origVal
 }
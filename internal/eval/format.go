@@ -0,0 +1,171 @@
+package eval
+
+import (
+	"fmt"
+	"lang/internal/core"
+	"lang/internal/env"
+	"lang/internal/parser"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// FormatValue renders a value the way print, println and string() show
+// it: '[1, 2, 3]' for arrays and 'Point{x: 1, y: 2}' for instances.
+func (e *Evaluator) FormatValue(v any) string {
+	return e.formatValue(v, parser.Position{Row: -1, Column: -1})
+}
+
+func (e *Evaluator) formatValue(v any, pos parser.Position) string {
+	return e.format(v, pos, false, make(map[*env.Env]bool))
+}
+
+// format quotes strings only when they are nested in arrays or instances.
+func (e *Evaluator) format(
+	v any,
+	pos parser.Position,
+	nested bool,
+	seen map[*env.Env]bool) string {
+
+	switch val := unwrapBuiltinValue(v).(type) {
+	case nil, core.NilValue:
+		return "nil"
+	case string:
+		if nested {
+			return strconv.Quote(val)
+		}
+		return val
+	case int:
+		return strconv.Itoa(val)
+	case float64:
+		return strconv.FormatFloat(val, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	case []any, []int, []float64:
+		parts := make([]string, arrayLen(val))
+		for i := range parts {
+			parts[i] = e.format(e.arrayGet(val, i), pos, true, seen)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case *env.Env:
+		if inst, ok := operatorMethod(val, "toString"); ok {
+			s := e.callOperator(inst, "toString", nil, pos)
+			if str, ok := s.(string); ok {
+				return str
+			}
+			return e.format(s, pos, nested, seen)
+		}
+		if seen[val] {
+			return val.Type + "{...}"
+		}
+		seen[val] = true
+		defer delete(seen, val)
+
+		var parts []string
+		if class := val.Parent; class != nil {
+			for _, name := range class.Fields {
+				sym, ok := val.Get(name)
+				if !ok || class.IsPrivate(name) {
+					continue
+				}
+				parts = append(parts,
+					name+": "+e.format(sym.Value(), pos, true, seen))
+			}
+		}
+		return val.Type + "{" + strings.Join(parts, ", ") + "}"
+	case core.Range:
+		if val.Step == 1 {
+			return fmt.Sprintf("%d..%d", val.Start, val.End)
+		}
+		return fmt.Sprintf("range(%d, %d, %d)", val.Start, val.End, val.Step)
+	case *env.FuncSymbol:
+		return "<func>"
+	case *generator:
+		return val.String()
+	case *task:
+		return val.String()
+	case *channel:
+		return val.String()
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// valuesEqual compares arrays element by element and instances of the
+// same class field by field, using 'eq' where a class defines it.
+func (e *Evaluator) valuesEqual(a, b any, pos parser.Position) bool {
+	return e.deepEqual(a, b, pos, make(map[[2]*env.Env]bool))
+}
+
+func (e *Evaluator) deepEqual(
+	a any,
+	b any,
+	pos parser.Position,
+	seen map[[2]*env.Env]bool) bool {
+
+	a = unwrapBuiltinValue(a)
+	b = unwrapBuiltinValue(b)
+	if isNilValue(a) || isNilValue(b) {
+		return isNilValue(a) && isNilValue(b)
+	}
+
+	switch x := a.(type) {
+	case int:
+		switch y := b.(type) {
+		case int:
+			return x == y
+		case float64:
+			return float64(x) == y
+		}
+		return false
+	case float64:
+		switch y := b.(type) {
+		case int:
+			return x == float64(y)
+		case float64:
+			return x == y
+		}
+		return false
+	case []any, []int, []float64:
+		if !isArray(b) || arrayLen(a) != arrayLen(b) {
+			return false
+		}
+		for i := 0; i < arrayLen(a); i++ {
+			if !e.deepEqual(e.arrayGet(a, i), e.arrayGet(b, i), pos, seen) {
+				return false
+			}
+		}
+		return true
+	case *env.Env:
+		y, ok := b.(*env.Env)
+		if !ok {
+			return false
+		}
+		if x == y {
+			return true
+		}
+		if x.Parent == nil || x.Parent != y.Parent {
+			return false
+		}
+		if inst, ok := operatorMethod(x, "eq"); ok {
+			res, _ := e.callOperator(inst, "eq", []any{y}, pos).(bool)
+			return res
+		}
+		pair := [2]*env.Env{x, y}
+		if seen[pair] {
+			return true
+		}
+		seen[pair] = true
+		equal := x.Len() == y.Len()
+		x.Each(func(name string, sym core.Symbol) {
+			if !equal {
+				return
+			}
+			other, ok := y.Get(name)
+			equal = ok && e.deepEqual(sym.Value(), other.Value(), pos, seen)
+		})
+		return equal
+	}
+
+	return reflect.DeepEqual(a, b)
+}
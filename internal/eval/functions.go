@@ -5,6 +5,7 @@ import (
 	"lang/internal/core"
 	"lang/internal/env"
 	"lang/internal/parser"
+	"slices"
 )
 
 func (e *Evaluator) evalFunctionDef(funcDef *parser.FunctionDefNode) any {
@@ -14,10 +15,32 @@ func (e *Evaluator) evalFunctionDef(funcDef *parser.FunctionDefNode) any {
 		funcDef.Body,
 		env.NewEnv(e.currentEnv, "function"),
 	)
+	annotate(e.currentEnv.Symbol(funcDef.Name), funcDef)
 	return 1
 }
 
+// annotate copies the parameter annotations, defaults and return type
+// of a function or method definition onto its symbol.
+func annotate(sym core.Symbol, def parser.Node) {
+	f, ok := sym.(*env.FuncSymbol)
+	if !ok {
+		return
+	}
+	switch d := def.(type) {
+	case *parser.FunctionDefNode:
+		f.ParamTypes, f.Defaults = d.ParamTypes, d.Defaults
+		f.Variadic, f.ReturnType = d.Variadic, d.ReturnType
+		f.Generator = d.IsGenerator
+	case *parser.StructMethodDef:
+		f.Body = d.Body
+		f.ParamTypes, f.Defaults = d.ParamTypes, d.Defaults
+		f.Variadic, f.ReturnType = d.Variadic, d.ReturnType
+		f.Generator = d.IsGenerator
+	}
+}
+
 func (e *Evaluator) evalFunctionCall(call *parser.FunctionCallNode) any {
+	tail := e.isTailCall(call)
 	ident, ok := call.Name.(*parser.IdentifierNode)
 	if !ok {
 		e.GenError("Function call must be on an identifier",
@@ -27,6 +50,17 @@ func (e *Evaluator) evalFunctionCall(call *parser.FunctionCallNode) any {
 
 	// builtins
 	if builtin, ok := e.Builtins[ident.Name]; ok {
+		if !e.allowBuiltin(ident.Name, call.Position) {
+			return nil
+		}
+		for _, arg := range call.Args {
+			if named, ok := arg.(*parser.NamedArgNode); ok {
+				e.GenError(fmt.Sprintf(
+					"Builtin '%s' doesn't take named arguments", ident.Name),
+					named.Position)
+				return nil
+			}
+		}
 		return builtin(e, call.Args, call.Position)
 	}
 
@@ -38,53 +72,164 @@ func (e *Evaluator) evalFunctionCall(call *parser.FunctionCallNode) any {
 		return nil
 	}
 
+	if structSym, ok := function.(*env.StructSymbol); ok {
+		args := make([]any, len(call.Args))
+		for i, arg := range call.Args {
+			args[i] = e.EvalNode(arg)
+			if args[i] == nil {
+				return nil
+			}
+		}
+		return e.constructInstance(structSym, args, call.Position)
+	}
+
 	f, ok := function.(*env.FuncSymbol)
 	if !ok {
 		e.GenError("Something very very wrong here", call.Position)
 		return nil
 	}
 
-	params := f.Params
-	if len(call.Args) != len(params) {
-		e.GenError(fmt.Sprintf(
-			"Function '%s' accepts %d, but passed only %d",
-			call.Name,
-			len(params),
-			len(call.Args)),
-			call.Position)
-
-		return nil
-	}
-
 	// 1. Eval args
 	argValues := make([]any, len(call.Args))
 	for i, arg := range call.Args {
-		argValues[i] = e.EvalNode(arg)
+		if argValues[i] = e.EvalNode(arg); argValues[i] == nil {
+			return nil
+		}
+	}
+	// functions of native modules may keep their arguments, so they get
+	// a copy. Otherwise argValues escapes to the heap on every call.
+	if f.NativeFunc != nil {
+		return e.invokeMethod(nil, nil, f, ident.Name, slices.Clone(argValues), call.Position, false)
 	}
 
-	// 2. Switch to the function's environment
-	prevEnv := e.currentEnv
-	callEnv := env.NewEnv(f.Env, ident.Name)
-	e.currentEnv = callEnv
+	c := callee{
+		fn:      f,
+		name:    ident.Name,
+		scope:   f.Env,
+		envType: ident.Name,
+		pos:     call.Position,
+	}
+	if tail {
+		return c.deferred(argValues)
+	}
+	return e.call(c, argValues)
+}
+
+// callFunction runs a user defined function with evaluated arguments.
+func (e *Evaluator) callFunction(
+	f *env.FuncSymbol,
+	name string,
+	argValues []any,
+	pos parser.Position) any {
+
+	return e.call(callee{
+		fn:      f,
+		name:    name,
+		scope:   f.Env,
+		envType: name,
+		pos:     pos,
+	}, argValues)
+}
+
+// callee is a call of a user defined function or method. As the value
+// of 'return' it is a tail call, made by the loop in call once the
+// returning call has finished.
+type callee struct {
+	fn   *env.FuncSymbol
+	name string
+	// env the call env is nested in, and self for instance methods
+	scope   *env.Env
+	self    *env.Env
+	envType string
+	pos     parser.Position
+	// class of a method, nil for functions
+	class *env.Env
+	// arguments of a tail call, others are passed to call
+	args []any
+}
+
+// deferred returns c with its arguments as a tail call. The arguments
+// are copied, so the caller's slice can stay on its stack.
+func (c *callee) deferred(args []any) *callee {
+	next := *c
+	next.args = slices.Clone(args)
+	return &next
+}
 
-	// 3. Add parameters to the new environment
-	for i, val := range argValues {
-		e.currentEnv.AddVarSymbol(f.Params[i],
-			e.resolveType(val, call.Position), val)
+func (c *callee) frame() callFrame {
+	return callFrame{name: c.name, pos: c.pos, class: c.class, self: c.self}
+}
+
+// call runs c in a new call env. Tail calls replace the finished call
+// instead of nesting in it, so tail recursion runs in constant stack.
+func (e *Evaluator) call(c callee, args []any) any {
+	if !e.enterCall(c.frame()) {
+		return nil
 	}
+	defer e.leaveCall()
 
-	// 4. Evaluate the function body
-	var result any
-	for _, stmt := range f.Body.Statements {
-		result = e.EvalNode(stmt)
-		if ret, ok := result.(core.ReturnValue); ok {
+	// calls with a return type, which the value of the tail call they
+	// made has to match
+	var callers []callee
+	for {
+		// 2. Switch to the function's environment
+		prevEnv := e.currentEnv
+		callEnv := e.pushEnv(c.scope, c.envType)
+		if c.self != nil {
+			callEnv.AddVarSymbol("self", c.self.Type, c.self)
+		}
+		e.currentEnv = callEnv
+
+		// 3. Add parameters to the new environment
+		if !e.bindArgs(callEnv, c.fn, c.name, args, c.pos) {
 			e.currentEnv = prevEnv
-			return ret.Value
+			return nil
 		}
+
+		var value any
+		if c.fn.Generator {
+			e.currentEnv = prevEnv
+			value = e.newGenerator(c.fn, callEnv, c.frame())
+		} else {
+			// 4. Evaluate the function body
+			result := e.evalFuncBlock(c.fn.Body)
+			e.popEnv(c.fn.Body, callEnv, prevEnv)
+			if ret, ok := result.(core.ReturnValue); ok {
+				if next, ok := ret.Value.(*callee); ok {
+					if c.fn.ReturnType != "" {
+						callers = append(callers, c)
+					}
+					e.frames[len(e.frames)-1] = next.frame()
+					c, args = *next, next.args
+					continue
+				}
+				result = ret.Value
+			}
+			if result == nil {
+				return nil
+			}
+			value = e.checkReturn(c.fn, c.name, result, c.pos)
+		}
+
+		for i := len(callers) - 1; i >= 0 && value != nil; i-- {
+			value = e.checkReturn(callers[i].fn, callers[i].name, value, callers[i].pos)
+		}
+		return value
 	}
+}
 
-	e.currentEnv = prevEnv
-	return result
+// namedArg is a 'name: value' call argument, bound by bindArgs.
+type namedArg struct {
+	name  string
+	value any
+}
+
+func (e *Evaluator) evalNamedArg(arg *parser.NamedArgNode) any {
+	value := e.EvalNode(arg.Value)
+	if value == nil {
+		return nil
+	}
+	return namedArg{name: arg.Name, value: value}
 }
 
 func (e *Evaluator) evalFuncBlock(block *parser.BlockNode) any {
@@ -94,6 +239,129 @@ func (e *Evaluator) evalFuncBlock(block *parser.BlockNode) any {
 		if ret, ok := result.(core.ReturnValue); ok {
 			return ret
 		}
+		if !e.checkLoopSignal(result) {
+			return nil
+		}
 	}
 	return result
 }
+
+// bindArgs adds the call arguments to callEnv as the parameters of f.
+// Named arguments are matched by name, missing ones take their default
+// value and a variadic parameter gets an array of the remaining
+// positional arguments. Every value is checked against its annotation.
+func (e *Evaluator) bindArgs(
+	callEnv *env.Env,
+	f *env.FuncSymbol,
+	name string,
+	args []any,
+	pos parser.Position) bool {
+
+	fixed := len(f.Params)
+	if f.Variadic {
+		fixed--
+	}
+	values := make([]any, len(f.Params))
+	rest := []any{}
+	positional := 0
+	seenNamed := false
+
+	for _, arg := range args {
+		if arg == nil {
+			return false
+		}
+		if named, ok := arg.(namedArg); ok {
+			seenNamed = true
+			i := slices.Index(f.Params, named.name)
+			if i < 0 || i >= fixed {
+				e.GenError(fmt.Sprintf(
+					"'%s' has no parameter '%s' that can be passed by name",
+					name, named.name), pos)
+				return false
+			}
+			if values[i] != nil {
+				e.GenError(fmt.Sprintf(
+					"Argument '%s' of '%s' is passed more than once",
+					named.name, name), pos)
+				return false
+			}
+			values[i] = named.value
+			continue
+		}
+		if seenNamed {
+			e.GenError(fmt.Sprintf(
+				"Positional argument after named arguments in call to '%s'",
+				name), pos)
+			return false
+		}
+		switch {
+		case positional < fixed:
+			values[positional] = arg
+		case f.Variadic:
+			rest = append(rest, arg)
+		default:
+			e.GenError(fmt.Sprintf(
+				"'%s' expects at most %d args, got %d",
+				name, fixed, len(args)), pos)
+			return false
+		}
+		positional++
+	}
+
+	for i, param := range f.Params {
+		declared := ""
+		if i < len(f.ParamTypes) {
+			declared = f.ParamTypes[i]
+		}
+		val := values[i]
+		if i == fixed {
+			val = rest
+			if declared != "" {
+				declared = "[]" + declared
+			}
+		} else if val == nil {
+			if i >= len(f.Defaults) || f.Defaults[i] == nil {
+				e.GenError(fmt.Sprintf(
+					"Missing argument '%s' in call to '%s'", param, name), pos)
+				return false
+			}
+			// defaults can refer to the parameters before them
+			prevEnv := e.currentEnv
+			e.currentEnv = callEnv
+			val = e.EvalNode(f.Defaults[i])
+			e.currentEnv = prevEnv
+			if val == nil {
+				return false
+			}
+		}
+		if declared != "" {
+			var ok bool
+			val, ok = e.checkType(declared, val, fmt.Sprintf(
+				"argument '%s' of '%s'", param, name), pos)
+			if !ok {
+				return false
+			}
+		}
+		callEnv.AddTypedVarSymbol(param, declared,
+			e.resolveType(val, pos), val)
+	}
+	return true
+}
+
+// checkReturn checks a returned value against the return annotation.
+func (e *Evaluator) checkReturn(
+	f *env.FuncSymbol,
+	name string,
+	value any,
+	pos parser.Position) any {
+
+	if f.ReturnType == "" {
+		return value
+	}
+	value, ok := e.checkType(f.ReturnType, value,
+		fmt.Sprintf("return value of '%s'", name), pos)
+	if !ok {
+		return nil
+	}
+	return value
+}
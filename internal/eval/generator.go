@@ -0,0 +1,255 @@
+package eval
+
+import (
+	"fmt"
+	"lang/internal/core"
+	"lang/internal/env"
+	"lang/internal/parser"
+	"maps"
+	"runtime"
+	"slices"
+	"sync"
+)
+
+// generator is the value returned by calling a function containing
+// 'yield'. Its body runs on its own goroutine and evaluator, but only
+// while a caller waits for the next value.
+type generator struct {
+	name    string
+	fn      *env.FuncSymbol
+	callEnv *env.Env
+	// frame the body runs in
+	frame callFrame
+	// serializes next and stop, tasks may share the generator
+	mu sync.Mutex
+	// set once started
+	body *genBody
+	done bool
+}
+
+// genBody is the started body of a generator. It doesn't refer to the
+// generator, so once nothing else does the generator is collected and
+// its body stopped.
+type genBody struct {
+	// evaluates the body
+	worker *Evaluator
+	// serializes switchTo
+	mu sync.Mutex
+	// true asks for the next value, false stops the body
+	resume chan bool
+	// yielded values, closed once the body has finished
+	values chan any
+	ended  bool
+	failed bool
+	// set by the worker once told to stop
+	stopped bool
+}
+
+// genBodies are the started generator bodies of an evaluator and its
+// forks that haven't finished.
+type genBodies struct {
+	mu   sync.Mutex
+	live map[*genBody]struct{}
+}
+
+// newGenerator returns a generator running f in callEnv, which already
+// holds the arguments. Nothing runs until the first value is asked for.
+func (e *Evaluator) newGenerator(
+	f *env.FuncSymbol,
+	callEnv *env.Env,
+	frame callFrame) *generator {
+
+	frame.generator = true
+	return &generator{
+		name:    frame.name,
+		fn:      f,
+		callEnv: callEnv,
+		frame:   frame,
+	}
+}
+
+func (e *Evaluator) startGenerator(g *generator) {
+	b := &genBody{
+		worker: e.fork(g.callEnv),
+		resume: make(chan bool),
+		values: make(chan any),
+	}
+	b.worker.generator = b
+	b.worker.frames = []callFrame{g.frame}
+	g.body = b
+
+	// the goroutine mustn't refer to g, or it would never be collected
+	body := g.fn.Body
+	bodies := e.generatorBodies()
+	bodies.mu.Lock()
+	bodies.live[b] = struct{}{}
+	bodies.mu.Unlock()
+	go func() {
+		defer func() {
+			bodies.mu.Lock()
+			delete(bodies.live, b)
+			bodies.mu.Unlock()
+		}()
+		b.run(body)
+	}()
+	runtime.AddCleanup(g, func(b *genBody) { go b.stop() }, b)
+}
+
+func (e *Evaluator) generatorBodies() *genBodies {
+	if e.bodies == nil {
+		e.bodies = &genBodies{live: make(map[*genBody]struct{})}
+	}
+	return e.bodies
+}
+
+// StopGenerators stops the started generators that haven't finished, so
+// their goroutines end, for an evaluator that won't run anything
+// anymore. Generators nothing refers to are stopped without it.
+func (e *Evaluator) StopGenerators() {
+	bodies := e.generatorBodies()
+	bodies.mu.Lock()
+	live := slices.Collect(maps.Keys(bodies.live))
+	bodies.mu.Unlock()
+	for _, b := range live {
+		b.stop()
+	}
+}
+
+func (b *genBody) run(body *parser.BlockNode) {
+	defer close(b.values)
+	if !<-b.resume {
+		return
+	}
+	// tasks spawned by the body belong to it
+	if b.worker.evalFuncBlock(body) == nil || !b.worker.waitTasks() {
+		b.failed = true
+	}
+}
+
+// switchTo hands control to the body and waits until it yields or ends.
+func (b *genBody) switchTo(resume bool) (any, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ended {
+		return nil, false
+	}
+	b.resume <- resume
+	value, more := <-b.values
+	b.ended = !more
+	return value, more
+}
+
+// stop makes the body unwind from its 'yield' like 'return;'.
+func (b *genBody) stop() {
+	b.switchTo(false)
+}
+
+// next runs the generator up to its next 'yield'. more is false once it
+// has finished, then value is nil if the body failed with an error.
+func (e *Evaluator) next(g *generator) (value any, more bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.done {
+		return core.NilValue{}, false
+	}
+	value, more = e.switchTo(g, true)
+	if !more {
+		g.done = true
+		if g.body.failed {
+			return nil, false
+		}
+		return core.NilValue{}, false
+	}
+	return value, true
+}
+
+// stop ends a generator that won't be iterated any further, so its
+// goroutine doesn't wait forever.
+func (e *Evaluator) stop(g *generator) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.done {
+		return
+	}
+	g.done = true
+	if g.body != nil {
+		e.switchTo(g, false)
+	}
+}
+
+// switchTo hands control to the body of g, starting it if needed. The
+// body runs as part of e's run, so it is stopped with it, and its
+// errors are e's.
+func (e *Evaluator) switchTo(g *generator, resume bool) (any, bool) {
+	if g.body == nil {
+		e.startGenerator(g)
+	}
+	b := g.body
+	b.worker.interrupt, b.worker.limits = e.interrupt, e.limits
+	value, more := b.switchTo(resume)
+	e.Errors = append(e.Errors, b.worker.Errors...)
+	b.worker.Errors = nil
+	return value, more
+}
+
+func (e *Evaluator) evalYield(stmt *parser.YieldNode) any {
+	b := e.generator
+	if b == nil {
+		e.GenError("'yield' outside of a generator", stmt.Position)
+		return nil
+	}
+	if b.stopped {
+		e.GenError("'yield' in a generator that was stopped", stmt.Position)
+		return nil
+	}
+
+	var value any = core.NilValue{}
+	if stmt.Value != nil {
+		if value = e.EvalNode(stmt.Value); value == nil {
+			return nil
+		}
+	}
+	b.values <- value
+	if !<-b.resume {
+		// stopped by the caller, unwind like 'return;'
+		b.stopped = true
+		return core.ReturnValue{Value: core.NilValue{}}
+	}
+	return core.NilValue{}
+}
+
+// generatorMethod implements 'gen.next()' and 'gen.next(fallback)',
+// the fallback (nil by default) is returned once gen is done.
+func (e *Evaluator) generatorMethod(g *generator, stmt *parser.StructMethodCall) any {
+	if stmt.IsField || stmt.MethodName != "next" {
+		e.GenError(fmt.Sprintf(
+			"Generators have no member '%s'", stmt.MethodName), stmt.Position)
+		return nil
+	}
+	if len(stmt.Args) > 1 {
+		e.GenError("next: expects at most one argument", stmt.Position)
+		return nil
+	}
+	value, more := e.next(g)
+	if more || value == nil || len(stmt.Args) == 0 {
+		return value
+	}
+	return e.EvalNode(stmt.Args[0])
+}
+
+// collect returns every item of an iterable as a new array.
+func (e *Evaluator) collect(value any, pos parser.Position) any {
+	items := []any{}
+	ok := e.forEachItem(value, pos, func(item any) bool {
+		items = append(items, item)
+		return true
+	})
+	if !ok {
+		return nil
+	}
+	return items
+}
+
+func (g *generator) String() string {
+	return fmt.Sprintf("<generator %s>", g.name)
+}
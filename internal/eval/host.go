@@ -0,0 +1,470 @@
+package eval
+
+import (
+	"fmt"
+	"lang/internal/core"
+	"lang/internal/env"
+	"lang/internal/parser"
+	"reflect"
+	"unicode"
+)
+
+// host holds the modules and classes registered by the program that
+// embeds the evaluator, shared with the evaluators of spawned tasks.
+type host struct {
+	modules map[string]map[string]any
+	classes map[reflect.Type]*hostClass
+}
+
+// hostClass is a Go struct type registered as a class. Its instances
+// are ordinary instances, copied into a struct for each method call and
+// updated from it after.
+type hostClass struct {
+	sym    *env.StructSymbol
+	typ    reflect.Type
+	fields []hostField
+}
+
+type hostField struct {
+	name  string
+	index int
+}
+
+var errorType = reflect.TypeFor[error]()
+
+func (e *Evaluator) registry() *host {
+	if e.host == nil {
+		e.host = &host{
+			modules: make(map[string]map[string]any),
+			classes: make(map[reflect.Type]*hostClass),
+		}
+	}
+	return e.host
+}
+
+// RegisterFunc makes the Go function fn the builtin name. Arguments are
+// converted to its parameter types, instances of registered classes to
+// their structs, and its result by ToValue. fn may return nothing, a
+// value, an error or a value and an error, a non nil error is a runtime
+// error.
+func (e *Evaluator) RegisterFunc(name string, fn any) error {
+	f, err := hostFunc(fn)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	e.Builtins[name] = func(e *Evaluator, args []parser.Node, pos parser.Position) any {
+		values := make([]any, len(args))
+		for i, arg := range args {
+			if values[i] = e.EvalNode(arg); values[i] == nil {
+				return nil
+			}
+		}
+		return e.callHost(name, f, values, pos)
+	}
+	return nil
+}
+
+// RegisterModule makes 'import name;' and 'import name > member;' import
+// members instead of reading name.lang. Functions are called like those
+// of RegisterFunc, other members are constants converted by ToValue.
+func (e *Evaluator) RegisterModule(name string, members map[string]any) error {
+	module := make(map[string]any, len(members))
+	for member, value := range members {
+		if reflect.TypeOf(value) != nil && reflect.TypeOf(value).Kind() == reflect.Func {
+			f, err := hostFunc(value)
+			if err != nil {
+				return fmt.Errorf("%s.%s: %w", name, member, err)
+			}
+			value = f
+		} else if _, err := e.ToValue(value); err != nil {
+			return fmt.Errorf("%s.%s: %w", name, member, err)
+		}
+		module[member] = value
+	}
+	e.registry().modules[name] = module
+	return nil
+}
+
+// RegisterClass declares the class name for the struct type of sample.
+// Its exported fields are the fields of the class, named by their 'lang'
+// tag or with a lower case first letter, and the values they have in
+// sample are their defaults. The exported methods of the struct pointer
+// are its methods, named the same way, and see the fields of the
+// instance they are called on.
+func (e *Evaluator) RegisterClass(name string, sample any) error {
+	v := reflect.ValueOf(sample)
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("%s: expected a struct, got %T", name, sample)
+	}
+	if e.Environment.SymbolExists(name) {
+		return fmt.Errorf("Class '%s' already exists", name)
+	}
+
+	classEnv := env.NewEnv(e.Environment, name)
+	c := &hostClass{
+		sym: &env.StructSymbol{Environment: classEnv, TypeName: name},
+		typ: v.Type(),
+	}
+	for i := range c.typ.NumField() {
+		field := c.typ.Field(i)
+		fieldName := field.Tag.Get("lang")
+		if !field.IsExported() || fieldName == "-" {
+			continue
+		}
+		if fieldName == "" {
+			fieldName = hostName(field.Name)
+		}
+		value, err := e.ToValue(v.Field(i).Interface())
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", name, fieldName, err)
+		}
+		c.fields = append(c.fields, hostField{name: fieldName, index: i})
+		classEnv.Fields = append(classEnv.Fields, fieldName)
+		classEnv.AddTypedVarSymbol(fieldName, hostType(field.Type),
+			e.resolveType(value, parser.Position{}), value)
+	}
+
+	ptr := reflect.PointerTo(c.typ)
+	for i := range ptr.NumMethod() {
+		method := ptr.Method(i)
+		methodName := hostName(method.Name)
+		if classEnv.SymbolExistsInCurrent(methodName) {
+			return fmt.Errorf("%s.%s: method has the name of a field", name, methodName)
+		}
+		if err := checkResults(method.Type); err != nil {
+			return fmt.Errorf("%s.%s: %w", name, methodName, err)
+		}
+		classEnv.Set(methodName, &env.FuncSymbol{
+			TypeName: name,
+			NativeFunc: func(ce core.Evaluator, self *env.Env, args []any, pos parser.Position) any {
+				e := ce.(*Evaluator)
+				recv, err := e.loadStruct(c, self)
+				if err != nil {
+					e.GenError(fmt.Sprintf("Type error: %s in '%s'", err, methodName), pos)
+					return nil
+				}
+				result := e.callHost(methodName, recv.Method(method.Index), args, pos)
+				if err := e.storeStruct(c, self, recv.Elem()); err != nil {
+					e.GenError(fmt.Sprintf("%s: %s", methodName, err), pos)
+					return nil
+				}
+				return result
+			},
+		})
+	}
+
+	e.registry().classes[c.typ] = c
+	e.Environment.AddStructSymbol(name, classEnv)
+	return nil
+}
+
+// hostName is the name of an exported Go field or method in the
+// language, 'Name' becomes 'name'.
+func hostName(name string) string {
+	runes := []rune(name)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
+
+// hostType is the annotation of a field of type t, "" if it has none.
+func hostType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "int"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "bool"
+	}
+	return ""
+}
+
+func hostFunc(fn any) (reflect.Value, error) {
+	f := reflect.ValueOf(fn)
+	if f.Kind() != reflect.Func || f.IsNil() {
+		return reflect.Value{}, fmt.Errorf("expected a function, got %T", fn)
+	}
+	return f, checkResults(f.Type())
+}
+
+func checkResults(t reflect.Type) error {
+	switch {
+	case t.NumOut() > 2, t.NumOut() == 2 && t.Out(1) != errorType:
+		return fmt.Errorf("%s should return at most a value and an error", t)
+	}
+	return nil
+}
+
+// callHost calls the Go function f with args converted to its
+// parameter types. Instances passed as struct pointers get the fields
+// f set.
+func (e *Evaluator) callHost(name string, f reflect.Value, args []any, pos parser.Position) (result any) {
+	t := f.Type()
+	fixed := t.NumIn()
+	if t.IsVariadic() {
+		fixed--
+	}
+	if len(args) < fixed || len(args) > fixed && !t.IsVariadic() {
+		expects := "%d args"
+		if t.IsVariadic() {
+			expects = "at least %d args"
+		}
+		e.GenError(fmt.Sprintf("'%s' expects "+expects+", got %d",
+			name, fixed, len(args)), pos)
+		return nil
+	}
+
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		param := t.In(min(i, t.NumIn()-1))
+		if i >= fixed {
+			param = param.Elem()
+		}
+		value, err := e.toGo(arg, param)
+		if err != nil {
+			e.GenError(fmt.Sprintf("Type error: %s in argument %d of '%s'",
+				err, i+1, name), pos)
+			return nil
+		}
+		in[i] = value
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			e.GenError(fmt.Sprintf("%s: panic: %v", name, r), pos)
+			result = nil
+		}
+	}()
+	out := f.Call(in)
+
+	for i, arg := range args {
+		if inst, c := e.hostInstance(arg); c != nil && in[i].Kind() == reflect.Pointer {
+			if err := e.storeStruct(c, inst, in[i].Elem()); err != nil {
+				e.GenError(fmt.Sprintf("%s: %s", name, err), pos)
+				return nil
+			}
+		}
+	}
+
+	if n := len(out); n > 0 && t.Out(n-1) == errorType {
+		if err, _ := out[n-1].Interface().(error); err != nil {
+			e.GenError(fmt.Sprintf("%s: %s", name, err), pos)
+			return nil
+		}
+		out = out[:n-1]
+	}
+	if len(out) == 0 {
+		return core.NilValue{}
+	}
+	value, err := e.ToValue(out[0].Interface())
+	if err != nil {
+		e.GenError(fmt.Sprintf("%s: %s", name, err), pos)
+		return nil
+	}
+	return value
+}
+
+// toGo converts the value v to the Go type t, the error says why it
+// can't.
+func (e *Evaluator) toGo(v any, t reflect.Type) (reflect.Value, error) {
+	v = unwrapBuiltinValue(v)
+	if _, ok := v.(core.NilValue); ok {
+		switch t.Kind() {
+		case reflect.Interface, reflect.Pointer, reflect.Slice, reflect.Map:
+			return reflect.Zero(t), nil
+		}
+		return reflect.Value{}, fmt.Errorf("cannot use nil as %s", t)
+	}
+	if inst, c := e.hostInstance(v); c != nil {
+		switch t {
+		case c.typ:
+			ptr, err := e.loadStruct(c, inst)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			return ptr.Elem(), nil
+		case reflect.PointerTo(c.typ):
+			return e.loadStruct(c, inst)
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, ok := v.(int); ok {
+			if reflect.Zero(t).OverflowInt(int64(n)) {
+				return reflect.Value{}, fmt.Errorf("%d overflows %s", n, t)
+			}
+			return reflect.ValueOf(n).Convert(t), nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, ok := v.(int); ok {
+			if n < 0 || reflect.Zero(t).OverflowUint(uint64(n)) {
+				return reflect.Value{}, fmt.Errorf("%d overflows %s", n, t)
+			}
+			return reflect.ValueOf(n).Convert(t), nil
+		}
+	case reflect.Float32, reflect.Float64:
+		switch n := v.(type) {
+		case int:
+			return reflect.ValueOf(float64(n)).Convert(t), nil
+		case float64:
+			return reflect.ValueOf(n).Convert(t), nil
+		}
+	case reflect.String:
+		if s, ok := v.(string); ok {
+			return reflect.ValueOf(s).Convert(t), nil
+		}
+	case reflect.Bool:
+		if b, ok := v.(bool); ok {
+			return reflect.ValueOf(b).Convert(t), nil
+		}
+	case reflect.Slice:
+		if !isArray(v) {
+			break
+		}
+		s := reflect.MakeSlice(t, arrayLen(v), arrayLen(v))
+		for i := range arrayLen(v) {
+			item, err := e.toGo(e.arrayGet(v, i), t.Elem())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("%w at index %d", err, i)
+			}
+			s.Index(i).Set(item)
+		}
+		return s, nil
+	case reflect.Interface:
+		g := e.FromValue(v)
+		if g == nil {
+			return reflect.Zero(t), nil
+		}
+		if reflect.TypeOf(g).AssignableTo(t) {
+			return reflect.ValueOf(g), nil
+		}
+	}
+	if reflect.TypeOf(v).AssignableTo(t) {
+		return reflect.ValueOf(v), nil
+	}
+	return reflect.Value{}, fmt.Errorf("cannot use %s as %s", e.typeName(v), t)
+}
+
+// hostInstance returns v and its class if v is an instance of a
+// registered class.
+func (e *Evaluator) hostInstance(v any) (*env.Env, *hostClass) {
+	inst, ok := v.(*env.Env)
+	if !ok || e.host == nil || inst.Parent == nil {
+		return nil, nil
+	}
+	for _, c := range e.host.classes {
+		if c.sym.Environment == inst.Parent {
+			return inst, c
+		}
+	}
+	return nil, nil
+}
+
+// hostClassOf returns the class registered for t or the type t points
+// to, nil if there is none.
+func (e *Evaluator) hostClassOf(t reflect.Type) *hostClass {
+	if e.host == nil {
+		return nil
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return e.host.classes[t]
+}
+
+// loadStruct returns a pointer to a struct with the fields of inst, or
+// an error naming the first field whose value the struct can't hold.
+func (e *Evaluator) loadStruct(c *hostClass, inst *env.Env) (reflect.Value, error) {
+	ptr := reflect.New(c.typ)
+	for _, field := range c.fields {
+		sym, ok := inst.Get(field.name)
+		if !ok {
+			continue
+		}
+		dst := ptr.Elem().Field(field.index)
+		value, err := e.toGo(sym.Value(), dst.Type())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("field '%s' of %s: %w", field.name, c.sym.TypeName, err)
+		}
+		dst.Set(value)
+	}
+	return ptr, nil
+}
+
+// storeStruct sets the fields of inst to those of the struct s, or
+// none of them if one can't be converted.
+func (e *Evaluator) storeStruct(c *hostClass, inst *env.Env, s reflect.Value) error {
+	values := make([]any, len(c.fields))
+	for i, field := range c.fields {
+		value, err := e.ToValue(s.Field(field.index).Interface())
+		if err != nil {
+			return fmt.Errorf("field '%s' of %s: %w", field.name, c.sym.TypeName, err)
+		}
+		values[i] = value
+	}
+	for i, field := range c.fields {
+		inst.UpdateInCurrent(field.name, values[i], e.resolveType(values[i], parser.Position{}))
+	}
+	return nil
+}
+
+// hostValue converts a struct, or a pointer to one, of a registered
+// class to a new instance.
+func (e *Evaluator) hostValue(c *hostClass, v reflect.Value) (any, error) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return core.NilValue{}, nil
+		}
+		v = v.Elem()
+	}
+	inst := newInstance(c.sym)
+	if err := e.storeStruct(c, inst, v); err != nil {
+		return nil, err
+	}
+	return inst, nil
+}
+
+// importHostModule imports a module registered by RegisterModule, the
+// way evalImport imports a file.
+func (e *Evaluator) importHostModule(stmt *parser.ImportNode, members map[string]any) any {
+	structName := capitalizeFirstLetter(stmt.File)
+	moduleEnv := env.NewEnv(e.currentEnv, structName)
+	for name, member := range members {
+		if f, ok := member.(reflect.Value); ok {
+			qualified := stmt.File + "." + name
+			moduleEnv.Set(name, &env.FuncSymbol{
+				TypeName: structName,
+				NativeFunc: func(ce core.Evaluator, _ *env.Env, args []any, pos parser.Position) any {
+					return ce.(*Evaluator).callHost(qualified, f, args, pos)
+				},
+			})
+			continue
+		}
+		value, _ := e.ToValue(member)
+		moduleEnv.AddVarSymbol(name, e.resolveType(value, stmt.Position), value)
+		moduleEnv.SetStatic(name)
+	}
+
+	if len(stmt.Symbols) == 0 {
+		e.currentEnv.AddStructSymbol(structName, moduleEnv)
+		e.currentEnv.AddVarSymbol(
+			stmt.File, structName, env.NewEnv(moduleEnv, structName))
+		return 1
+	}
+	for _, symbol := range stmt.Symbols {
+		sym, ok := moduleEnv.Get(symbol)
+		if !ok {
+			e.GenError(fmt.Sprintf("Symbol '%s' not found", symbol), stmt.Position)
+			return nil
+		}
+		e.currentEnv.Set(symbol, sym)
+	}
+	return 1
+}
@@ -9,6 +9,14 @@ import (
 )
 
 func (e *Evaluator) evalImport(stmt *parser.ImportNode) any {
+	if !e.allowModule(stmt.File, stmt.Position) {
+		return nil
+	}
+	if e.host != nil {
+		if members, ok := e.host.modules[stmt.File]; ok {
+			return e.importHostModule(stmt, members)
+		}
+	}
 	fileName := stmt.File + ".lang"
 	data, err := os.ReadFile(fileName)
 	if err != nil {
@@ -32,7 +40,7 @@ func (e *Evaluator) evalImport(stmt *parser.ImportNode) any {
 	mnode, errs := e.parser.Parse()
 	if len(errs) != 0 {
 		for _, err := range errs {
-			fmt.Println(err)
+			e.printError(err.Error() + "\n")
 		}
 		return nil
 	}
@@ -49,23 +57,23 @@ func (e *Evaluator) evalImport(stmt *parser.ImportNode) any {
 
 		importEnv := env.NewEnv(e.currentEnv, structName)
 		e.currentEnv.AddStructSymbol(structName, importEnv)
-		prevEnv := e.currentEnv
-
 		for _, stmt := range mnode.Nodes {
 			switch def := stmt.(type) {
 			case *parser.FunctionDefNode: {
-				e.currentEnv = importEnv
-				e.currentEnv.AddStructMethod(
+				importEnv.AddStructMethod(
 					structName,
 					def.Name,
 					def.Parameters,
 					def.Body.Statements,
 					nil,
 					)
+				annotate(importEnv.Symbol(def.Name), def)
+				if def.IsPrivate {
+					importEnv.SetPrivate(def.Name)
+				}
 			}
 			case *parser.StructMethodDef: {
-				strEnv := e.currentEnv.FindStructSymbol(def.StructName)
-				e.currentEnv = importEnv
+				strEnv := importEnv.FindStructSymbol(def.StructName)
 				if strEnv == nil {
 					e.GenError(fmt.Sprintf(
 						"Class '%s' not found",
@@ -80,9 +88,25 @@ func (e *Evaluator) evalImport(stmt *parser.ImportNode) any {
 					def.Body.Statements,
 					nil,
 					)
+				annotate(strEnv.Symbol(def.MethodName), def)
 			}
 			case *parser.StructDefNode: {
-				res := e.evalStructDef(def)
+				// private classes are only visible to the module itself
+				scope := e.currentEnv
+				if def.IsPrivate {
+					scope = importEnv
+				}
+				res := e.evalIn(scope, func() any { return e.evalStructDef(def) })
+				if res == nil {
+					return nil
+				}
+			}
+			case *parser.EnumDefNode: {
+				scope := e.currentEnv
+				if def.IsPrivate {
+					scope = importEnv
+				}
+				res := e.evalIn(scope, func() any { return e.evalEnumDef(def) })
 				if res == nil {
 					return nil
 				}
@@ -90,22 +114,49 @@ func (e *Evaluator) evalImport(stmt *parser.ImportNode) any {
 			}
 		}
 
-		e.currentEnv = prevEnv
-
 		e.currentEnv.AddVarSymbol(
 			varName, structName, env.NewEnv(importEnv, structName))
 
 		return 1
 	} else {
+		// Evaluate the module declarations in their own env so selected
+		// symbols can still reach helpers that were not imported.
+		moduleEnv := env.NewEnv(e.currentEnv, capitalizeFirstLetter(stmt.File))
+		for _, node := range mnode.Nodes {
+			switch node.(type) {
+			case *parser.FunctionDefNode, *parser.StructDefNode,
+				*parser.StructMethodDef, *parser.VarDefNode,
+				*parser.EnumDefNode:
+				if e.evalIn(moduleEnv, func() any { return e.EvalNode(node) }) == nil {
+					return nil
+				}
+			}
+		}
+
 		for _, symbol := range stmt.Symbols {
 			node, err := mnode.Find(symbol)
 			if err != nil {
 				e.GenError(err.Error(), stmt.Position)
 				return nil
 			}
-			e.EvalNode(node)
+			if parser.IsPrivateDecl(node) {
+				e.GenError(fmt.Sprintf(
+					"Symbol '%s' is private in '%s'", symbol, stmt.File),
+					stmt.Position)
+				return nil
+			}
+			e.currentEnv.Set(symbol, moduleEnv.Symbol(symbol))
 		}
 		return 1
 
 	}
 }
+
+// evalIn runs eval with scope as the current env, and restores the
+// current env however eval returns.
+func (e *Evaluator) evalIn(scope *env.Env, eval func() any) any {
+	prevEnv := e.currentEnv
+	e.currentEnv = scope
+	defer func() { e.currentEnv = prevEnv }()
+	return eval()
+}
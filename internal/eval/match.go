@@ -0,0 +1,136 @@
+package eval
+
+import (
+	"fmt"
+	"lang/internal/core"
+	"lang/internal/env"
+	"lang/internal/parser"
+)
+
+// evalMatch evaluates the subject once and runs the first arm with a
+// matching pattern. The match is nil when no arm matches.
+func (e *Evaluator) evalMatch(stmt *parser.MatchNode) any {
+	subject := e.EvalNode(stmt.Subject)
+	if subject == nil {
+		return nil
+	}
+	if ret, ok := subject.(core.ReturnValue); ok {
+		subject = ret.Value
+	}
+
+	for _, arm := range stmt.Arms {
+		for _, pattern := range arm.Patterns {
+			matched, ok := e.matchPattern(pattern, subject)
+			if !ok {
+				return nil
+			}
+			if !matched {
+				continue
+			}
+			if body, isBlock := arm.Body.(*parser.BlockNode); isBlock {
+				return e.evalBlock(body)
+			}
+			return e.EvalNode(arm.Body)
+		}
+	}
+	return core.NilValue{}
+}
+
+// matchPattern reports whether value matches pattern, ok is false when
+// the pattern itself failed to evaluate.
+func (e *Evaluator) matchPattern(pattern parser.Node, value any) (matched bool, ok bool) {
+	switch pat := pattern.(type) {
+	case *parser.WildcardPattern:
+		return true, true
+	case *parser.ValuePattern:
+		expected := e.EvalNode(pat.Value)
+		if expected == nil {
+			return false, false
+		}
+		return e.valuesEqual(value, expected, pat.Position), true
+	case *parser.RangePattern:
+		return e.matchRange(pat, value)
+	case *parser.TypePattern:
+		return e.matchType(pat, value)
+	case *parser.StructPattern:
+		return e.matchStruct(pat, value)
+	}
+	e.GenError(fmt.Sprintf("Unknown pattern: %T", pattern),
+		parser.Position{Row: -1, Column: -1})
+	return false, false
+}
+
+// matchRange matches numbers in [Low, High).
+func (e *Evaluator) matchRange(pat *parser.RangePattern, value any) (bool, bool) {
+	low, lok := toFloat(unwrapBuiltinValue(e.EvalNode(pat.Low)))
+	high, hok := toFloat(unwrapBuiltinValue(e.EvalNode(pat.High)))
+	if !lok || !hok {
+		e.GenError("Range pattern bounds must be int or float", pat.Position)
+		return false, false
+	}
+	v, isNumber := toFloat(unwrapBuiltinValue(value))
+	return isNumber && v >= low && v < high, true
+}
+
+// matchType matches values of the type named by pat, or the value of
+// the variable it names if there is no such type.
+func (e *Evaluator) matchType(pat *parser.TypePattern, value any) (bool, bool) {
+	value = unwrapBuiltinValue(value)
+	switch pat.TypeName {
+	case "int", "float", "string", "bool", "nil":
+		return e.resolveType(value, pat.Position) == pat.TypeName, true
+	case "array":
+		return isArray(value), true
+	}
+
+	switch sym := e.currentEnv.FindSymbol(pat.TypeName).(type) {
+	case *env.StructSymbol:
+		inst, ok := value.(*env.Env)
+		return ok && inst.Type == pat.TypeName, true
+	case *env.VarSymbol:
+		return e.valuesEqual(value, sym.Value(), pat.Position), true
+	}
+	e.GenError(fmt.Sprintf(
+		"Unknown type '%s' in match pattern", pat.TypeName),
+		pat.Position)
+	return false, false
+}
+
+func (e *Evaluator) matchStruct(pat *parser.StructPattern, value any) (bool, bool) {
+	matched, ok := e.matchType(&parser.TypePattern{
+		Position: pat.Position,
+		TypeName: pat.TypeName,
+	}, value)
+	if !matched || !ok {
+		return matched, ok
+	}
+
+	inst := value.(*env.Env)
+	for _, field := range pat.Fields {
+		if !e.checkMemberAccess(inst, inst.Parent, field.Name, pat.Position) {
+			return false, false
+		}
+		fieldSym, exists := inst.Get(field.Name)
+		if !exists {
+			e.GenError(fmt.Sprintf(
+				"Field '%s' not found in class '%s'", field.Name, pat.TypeName),
+				pat.Position)
+			return false, false
+		}
+		matched, ok := e.matchPattern(field.Pattern, fieldSym.Value())
+		if !matched || !ok {
+			return matched, ok
+		}
+	}
+	return true, true
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,129 @@
+package eval
+
+import (
+	"lang/internal/env"
+	"lang/internal/parser"
+)
+
+// Methods a class can define to overload operators.
+var binaryOperatorMethods = map[string]string{
+	"+":  "add",
+	"-":  "sub",
+	"*":  "mul",
+	"/":  "div",
+	"==": "eq",
+	"<":  "lt",
+	">":  "gt",
+	"<=": "le",
+	">=": "ge",
+}
+
+var unaryOperatorMethods = map[string]string{
+	"-": "neg",
+	"!": "not",
+}
+
+// operatorMethod returns v as an instance when its class defines the
+// special method name.
+func operatorMethod(v any, name string) (*env.Env, bool) {
+	inst, ok := v.(*env.Env)
+	if !ok || inst.Parent == nil || inst.Parent.IsStatic(name) {
+		return nil, false
+	}
+	_, ok = inst.Parent.Symbol(name).(*env.FuncSymbol)
+	return inst, ok
+}
+
+// invokeOperator calls the special method name of inst. Like any other
+// member, it can't be reached from outside its class when it's private.
+func (e *Evaluator) invokeOperator(
+	inst *env.Env,
+	name string,
+	args []any,
+	pos parser.Position) any {
+
+	if !e.checkMemberAccess(inst, inst.Parent, name, pos) {
+		return nil
+	}
+	return e.evalStructMethodCall(inst, name, args, pos)
+}
+
+func (e *Evaluator) callOperator(
+	inst *env.Env,
+	name string,
+	args []any,
+	pos parser.Position) any {
+
+	return unwrapBuiltinValue(e.invokeOperator(inst, name, args, pos))
+}
+
+// evalBinaryOverload dispatches a binary operator to the left operand's
+// special method. Comparisons with nil keep their default meaning.
+func (e *Evaluator) evalBinaryOverload(
+	op string,
+	left any,
+	right any,
+	pos parser.Position) (any, bool) {
+
+	if op == "==" || op == "!=" {
+		if isNilValue(left) || isNilValue(right) {
+			return nil, false
+		}
+		inst, ok := operatorMethod(left, "eq")
+		if !ok {
+			return nil, false
+		}
+		res := e.callOperator(inst, "eq", []any{right}, pos)
+		if b, isBool := res.(bool); isBool && op == "!=" {
+			return !b, true
+		}
+		return res, true
+	}
+
+	name, ok := binaryOperatorMethods[op]
+	if !ok {
+		return nil, false
+	}
+	if inst, ok := operatorMethod(left, name); ok {
+		return e.callOperator(inst, name, []any{right}, pos), true
+	}
+
+	// derive the missing comparisons from 'lt'
+	switch op {
+	case ">":
+		if inst, ok := operatorMethod(right, "lt"); ok {
+			return e.callOperator(inst, "lt", []any{left}, pos), true
+		}
+	case "<=":
+		if inst, ok := operatorMethod(right, "lt"); ok {
+			return negate(e.callOperator(inst, "lt", []any{left}, pos)), true
+		}
+	case ">=":
+		if inst, ok := operatorMethod(left, "lt"); ok {
+			return negate(e.callOperator(inst, "lt", []any{right}, pos)), true
+		}
+	}
+	return nil, false
+}
+
+func (e *Evaluator) evalUnaryOverload(
+	op string,
+	value any,
+	pos parser.Position) (any, bool) {
+
+	name, ok := unaryOperatorMethods[op]
+	if !ok {
+		return nil, false
+	}
+	if inst, ok := operatorMethod(value, name); ok {
+		return e.callOperator(inst, name, nil, pos), true
+	}
+	return nil, false
+}
+
+func negate(v any) any {
+	if b, ok := v.(bool); ok {
+		return !b
+	}
+	return v
+}
@@ -0,0 +1,118 @@
+package eval
+
+import (
+	"fmt"
+	"lang/internal/core"
+	"lang/internal/env"
+	"lang/internal/parser"
+)
+
+// builtinRange implements 'range(end)', 'range(start, end)' and
+// 'range(start, end, step)'. Values are produced while iterating, so
+// no array is allocated.
+func builtinRange(e *Evaluator, args []parser.Node, pos parser.Position) any {
+	if len(args) < 1 || len(args) > 3 {
+		e.GenError("range: expects one to three arguments", pos)
+		return nil
+	}
+	bounds := make([]int, len(args))
+	for i, arg := range args {
+		n, ok := unwrapBuiltinValue(e.EvalNode(arg)).(int)
+		if !ok {
+			e.GenError("range: arguments must be ints", pos)
+			return nil
+		}
+		bounds[i] = n
+	}
+
+	r := core.Range{End: bounds[0], Step: 1}
+	if len(bounds) > 1 {
+		r.Start, r.End = bounds[0], bounds[1]
+	}
+	if len(bounds) > 2 {
+		r.Step = bounds[2]
+	}
+	if r.Step == 0 {
+		e.GenError("range: step cannot be zero", pos)
+		return nil
+	}
+	return r
+}
+
+// evalRange evaluates 'start..end'.
+func (e *Evaluator) evalRange(node *parser.RangeNode) any {
+	start, sok := unwrapBuiltinValue(e.EvalNode(node.Start)).(int)
+	end, eok := unwrapBuiltinValue(e.EvalNode(node.End)).(int)
+	if !sok || !eok {
+		e.GenError("Range bounds must be ints", node.Position)
+		return nil
+	}
+	return core.Range{Start: start, End: end, Step: 1}
+}
+
+// forEachItem calls yield with every item of an array, string, range or
+// enum until yield returns false. It reports false if value can't be
+// iterated.
+func (e *Evaluator) forEachItem(
+	value any,
+	pos parser.Position,
+	yield func(item any) bool) bool {
+
+	switch v := unwrapBuiltinValue(value).(type) {
+	case core.Range:
+		for i := 0; i < v.Len(); i++ {
+			if !yield(v.At(i)) {
+				break
+			}
+		}
+	case []any, []int, []float64:
+		for i := 0; i < arrayLen(v); i++ {
+			if !yield(e.arrayGet(v, i)) {
+				break
+			}
+		}
+	case string:
+		for _, r := range v {
+			if !yield(e.createString(string(r))) {
+				break
+			}
+		}
+	case *generator:
+		for {
+			item, more := e.next(v)
+			if !more {
+				return item != nil
+			}
+			if !yield(item) {
+				e.stop(v)
+				break
+			}
+		}
+	case *channel:
+		for {
+			item, ok := e.receive(v)
+			if !ok {
+				return !e.halted()
+			}
+			if !yield(item) {
+				break
+			}
+		}
+	case *env.Env:
+		if v.Members == nil {
+			e.GenError(fmt.Sprintf(
+				"Cannot iterate over instance of '%s'", v.Type), pos)
+			return false
+		}
+		for _, member := range v.Members {
+			if !yield(member) {
+				break
+			}
+		}
+	default:
+		e.GenError(fmt.Sprintf(
+			"Cannot iterate over '%s'", e.resolveType(v, pos)), pos)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,292 @@
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"lang/internal/parser"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime/metrics"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Sandbox restricts what a program may do, for running untrusted
+// scripts. Set it as Evaluator.Sandbox before calling Eval. Breaking a
+// limit stops the program with a runtime error.
+type Sandbox struct {
+	// Builtins the program may call, nil allows every builtin
+	Builtins []string
+	// Modules the program may import, nil allows none. Modules are
+	// trusted code, they are read from outside FSRoot.
+	Modules []string
+	// FSRoot is the directory readAll and write work in, paths are
+	// relative to it and can't leave it. Empty allows no file access.
+	FSRoot string
+	// ReadOnly forbids write
+	ReadOnly bool
+	// Hosts fetch may connect to, nil allows none
+	Hosts []string
+	// MaxSteps limits the number of evaluated nodes, 0 is unlimited
+	MaxSteps int64
+	// MaxMemory limits the heap in bytes, 0 is unlimited. It is checked
+	// every few thousand steps and before strings are joined and arrays
+	// made, against the live heap of the last garbage collection plus
+	// the strings and arrays made since. The heap is that of the whole
+	// process, not of the program: it includes the memory of the host
+	// program and of every other program it runs.
+	MaxMemory uint64
+	// MaxTasks limits the spawned tasks running at once, 0 is unlimited
+	MaxTasks int64
+	// Timeout limits the wall clock time of Eval, 0 is unlimited
+	Timeout time.Duration
+}
+
+// steps between the memory checks of a sandbox
+const memoryCheckSteps = 1024
+
+// limits is the state of a sandbox while a program runs, shared with
+// the evaluators of spawned tasks.
+type limits struct {
+	*Sandbox
+	steps atomic.Int64
+	tasks atomic.Int64
+	root  *os.Root
+	timer *time.Timer
+	// guards cycle and reserved
+	mu sync.Mutex
+	// the garbage collection reserved counts the bytes since
+	cycle    uint64
+	reserved uint64
+}
+
+func (e *Evaluator) startSandbox() bool {
+	l := &limits{Sandbox: e.Sandbox}
+	if l.FSRoot != "" {
+		root, err := os.OpenRoot(l.FSRoot)
+		if err != nil {
+			e.Errors = append(e.Errors, fmt.Errorf("sandbox: %w", err))
+			return false
+		}
+		l.root = root
+	}
+	if l.Timeout > 0 {
+		stop := e.interrupt
+		l.timer = time.AfterFunc(l.Timeout, func() {
+			stop.halt(fmt.Errorf("timeout: ran for more than %s", l.Timeout))
+		})
+	}
+	e.limits = l
+	return true
+}
+
+// stopSandbox releases the sandbox of a finished program.
+func (e *Evaluator) stopSandbox() {
+	l := e.limits
+	if l.timer != nil {
+		l.timer.Stop()
+	}
+	if l.root != nil {
+		l.root.Close()
+	}
+	e.limits = nil
+}
+
+// step counts node against the sandbox limits before it's evaluated.
+// Once the program is stopped every node fails, GenError adds no errors
+// and the reason is reported by Eval.
+func (e *Evaluator) step(node parser.Node) bool {
+	if e.interrupt.halted() {
+		return false
+	}
+	l := e.limits
+	if l == nil {
+		return true
+	}
+	n := l.steps.Add(1)
+	if l.MaxSteps > 0 && n > l.MaxSteps {
+		e.interrupt.halt(limitError(node, fmt.Sprintf(
+			"step limit of %d exceeded", l.MaxSteps)))
+		return false
+	}
+	if n%memoryCheckSteps == 0 && l.MaxMemory > 0 {
+		// catches what grows without reserving, like many small values
+		l.mu.Lock()
+		ok := l.heap() <= l.MaxMemory
+		l.mu.Unlock()
+		return ok || e.exceedMemory(node)
+	}
+	return true
+}
+
+// reserve checks that size more bytes fit in the memory limit before a
+// string or array is made.
+func (e *Evaluator) reserve(size uint64, pos parser.Position) bool {
+	l := e.limits
+	if l == nil || l.MaxMemory == 0 {
+		return true
+	}
+	if size > l.MaxMemory {
+		return e.exceedMemory(pos)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.heap() > l.MaxMemory-size {
+		return e.exceedMemory(pos)
+	}
+	l.reserved += size
+	return true
+}
+
+// heap bounds the live heap without forcing a collection: the heap
+// live after the last one, plus the bytes reserved since.
+func (l *limits) heap() uint64 {
+	live, cycle := heapSize()
+	if cycle != l.cycle {
+		l.cycle, l.reserved = cycle, 0
+	}
+	return live + l.reserved
+}
+
+// allowTask counts a task spawned at node against the sandbox limits,
+// until it calls finishTask.
+func (e *Evaluator) allowTask(node parser.Node) bool {
+	l := e.limits
+	if l == nil {
+		return true
+	}
+	if n := l.tasks.Add(1); l.MaxTasks > 0 && n > l.MaxTasks {
+		l.tasks.Add(-1)
+		e.interrupt.halt(limitError(node, fmt.Sprintf(
+			"task limit of %d exceeded", l.MaxTasks)))
+		return false
+	}
+	return true
+}
+
+// finishTask stops counting a task that allowTask let start.
+func (e *Evaluator) finishTask() {
+	if l := e.limits; l != nil {
+		l.tasks.Add(-1)
+	}
+}
+
+// exceedMemory stops the program for breaking the memory limit at at, a
+// node or position.
+func (e *Evaluator) exceedMemory(at any) bool {
+	e.interrupt.halt(limitError(at, fmt.Sprintf(
+		"memory limit of %d bytes exceeded", e.limits.MaxMemory)))
+	return false
+}
+
+// heapSize returns the heap live after the last garbage collection and
+// the number of collections so far.
+func heapSize() (live, cycle uint64) {
+	sample := []metrics.Sample{
+		{Name: "/gc/heap/live:bytes"},
+		{Name: "/gc/cycles/total:gc-cycles"},
+	}
+	metrics.Read(sample)
+	return sample[0].Value.Uint64(), sample[1].Value.Uint64()
+}
+
+func limitError(at any, msg string) error {
+	if n, ok := at.(interface{ Pos() parser.Position }); ok {
+		pos := n.Pos()
+		return fmt.Errorf("%d, %d: %s", pos.Row, pos.Column, msg)
+	}
+	return errors.New(msg)
+}
+
+func (e *Evaluator) allowBuiltin(name string, pos parser.Position) bool {
+	l := e.limits
+	if l == nil || l.Builtins == nil || slices.Contains(l.Builtins, name) {
+		return true
+	}
+	e.GenError(fmt.Sprintf("Builtin '%s' is not allowed in the sandbox", name), pos)
+	return false
+}
+
+func (e *Evaluator) allowModule(name string, pos parser.Position) bool {
+	l := e.limits
+	if l == nil || slices.Contains(l.Modules, name) {
+		return true
+	}
+	e.GenError(fmt.Sprintf("Module '%s' is not allowed in the sandbox", name), pos)
+	return false
+}
+
+// readFile reads a file for readAll, from FSRoot in a sandbox.
+func (e *Evaluator) readFile(name string) ([]byte, error) {
+	l := e.limits
+	if l == nil {
+		return os.ReadFile(name)
+	}
+	if l.root == nil {
+		return nil, errors.New("file access is not allowed in the sandbox")
+	}
+	f, err := l.root.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// writeFile writes a file for write, in FSRoot in a sandbox.
+func (e *Evaluator) writeFile(name string, data []byte) error {
+	l := e.limits
+	if l == nil {
+		return os.WriteFile(name, data, 0644)
+	}
+	if l.root == nil {
+		return errors.New("file access is not allowed in the sandbox")
+	}
+	if l.ReadOnly {
+		return errors.New("files are read-only in the sandbox")
+	}
+	f, err := l.root.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// fetchTimeout limits a request of fetch, which also stops with the
+// program.
+const fetchTimeout = 30 * time.Second
+
+// get fetches rawURL for fetch. In a sandbox the host, and the host of
+// every redirect, has to be in Hosts.
+func (e *Evaluator) get(rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(e.context(), http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Timeout: fetchTimeout}
+	l := e.limits
+	if l == nil {
+		return client.Do(req)
+	}
+	allow := func(u *url.URL) error {
+		if !slices.Contains(l.Hosts, u.Hostname()) {
+			return fmt.Errorf("host '%s' is not allowed in the sandbox", u.Hostname())
+		}
+		return nil
+	}
+	if err := allow(req.URL); err != nil {
+		return nil, err
+	}
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return allow(req.URL)
+	}
+	return client.Do(req)
+}
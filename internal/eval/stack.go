@@ -0,0 +1,94 @@
+package eval
+
+import (
+	"fmt"
+	"lang/internal/env"
+	"lang/internal/parser"
+	"strings"
+)
+
+// DefaultMaxCallDepth is the call depth allowed when MaxCallDepth is 0.
+// Each call takes a few KB of Go stack, so this stays well below the Go
+// stack limit.
+const DefaultMaxCallDepth = 10000
+
+// frames shown at each end of a stack overflow trace
+const traceFrames = 5
+
+// callFrame is a call in progress, or a running generator body.
+type callFrame struct {
+	name string
+	pos  parser.Position
+	// class of a method and the instance it runs on, through which
+	// it may reach the private members of the class
+	class     *env.Env
+	self      *env.Env
+	generator bool
+}
+
+func (e *Evaluator) maxCallDepth() int {
+	if e.MaxCallDepth > 0 {
+		return e.MaxCallDepth
+	}
+	return DefaultMaxCallDepth
+}
+
+// enterCall pushes the frame of a call, or reports a stack overflow
+// when the calls are nested too deep.
+func (e *Evaluator) enterCall(frame callFrame) bool {
+	if len(e.frames) >= e.maxCallDepth() {
+		e.GenError(e.stackOverflow(), frame.pos)
+		return false
+	}
+	e.frames = append(e.frames, frame)
+	return true
+}
+
+func (e *Evaluator) leaveCall() {
+	e.frames = e.frames[:len(e.frames)-1]
+}
+
+// stackOverflow describes the error with the innermost and outermost
+// calls in progress.
+func (e *Evaluator) stackOverflow() string {
+	var trace strings.Builder
+	fmt.Fprintf(&trace, "stack overflow: more than %d nested calls", e.maxCallDepth())
+	for i := len(e.frames) - 1; i >= 0; i-- {
+		if i == len(e.frames)-1-traceFrames && i >= traceFrames {
+			fmt.Fprintf(&trace, "\n    ... %d more", i+1-traceFrames)
+			i = traceFrames
+			continue
+		}
+		f := e.frames[i]
+		if f.generator {
+			fmt.Fprintf(&trace, "\n    in generator %s", f.name)
+			continue
+		}
+		fmt.Fprintf(&trace, "\n    %s called at %d, %d", f.name, f.pos.Row, f.pos.Column)
+	}
+	return trace.String()
+}
+
+// currentFrame returns the frame of the running call, the zero frame
+// outside of calls.
+func (e *Evaluator) currentFrame() callFrame {
+	if len(e.frames) == 0 {
+		return callFrame{}
+	}
+	return e.frames[len(e.frames)-1]
+}
+
+// canTailCall reports whether a 'return' can leave the current call
+// before making the call it returns. Generator bodies can't, nothing
+// would make the call once they have finished.
+func (e *Evaluator) canTailCall() bool {
+	return len(e.frames) > 0 && !e.frames[len(e.frames)-1].generator
+}
+
+// isTailCall reports whether call is the value of a 'return', and can be
+// made once the current call has finished.
+func (e *Evaluator) isTailCall(call parser.Node) bool {
+	tail := e.tailCall == call
+	e.tailCall = nil
+	return tail
+}
@@ -0,0 +1,102 @@
+package eval
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+func (e *Evaluator) stdin() io.Reader {
+	if e.Stdin == nil {
+		return os.Stdin
+	}
+	return e.Stdin
+}
+
+func (e *Evaluator) stdout() io.Writer {
+	if e.Stdout == nil {
+		return os.Stdout
+	}
+	return e.Stdout
+}
+
+func (e *Evaluator) stderr() io.Writer {
+	if e.Stderr == nil {
+		return os.Stderr
+	}
+	return e.Stderr
+}
+
+// outputLock returns the lock serializing writes to Stdout and Stderr,
+// shared with the evaluators of spawned tasks so the writers don't have
+// to be safe for concurrent use.
+func (e *Evaluator) outputLock() *sync.Mutex {
+	if e.outMu == nil {
+		e.outMu = new(sync.Mutex)
+	}
+	return e.outMu
+}
+
+// print writes s to Stdout in one piece.
+func (e *Evaluator) print(s string) {
+	mu := e.outputLock()
+	mu.Lock()
+	defer mu.Unlock()
+	io.WriteString(e.stdout(), s)
+}
+
+// printError writes s to Stderr in one piece.
+func (e *Evaluator) printError(s string) {
+	mu := e.outputLock()
+	mu.Lock()
+	defer mu.Unlock()
+	io.WriteString(e.stderr(), s)
+}
+
+// inputReader reads Stdin for input. A read the program stopped waiting
+// for goes on, and what it reads is returned by the next input.
+type inputReader struct {
+	// holds a token while a read is waited for
+	turn chan struct{}
+	// the read going on, if any
+	pending chan string
+}
+
+// inputs returns the reader of Stdin, shared with the evaluators of
+// spawned tasks.
+func (e *Evaluator) inputs() *inputReader {
+	if e.in == nil {
+		e.in = &inputReader{turn: make(chan struct{}, 1)}
+	}
+	return e.in
+}
+
+// readInput reads from Stdin with fmt.Fscanln, ok is false if the
+// program was stopped first.
+func (e *Evaluator) readInput() (input string, ok bool) {
+	r := e.inputs()
+	select {
+	case r.turn <- struct{}{}:
+	case <-e.interrupted():
+		return "", false
+	}
+	defer func() { <-r.turn }()
+
+	if r.pending == nil {
+		pending := make(chan string, 1)
+		go func(in io.Reader) {
+			var input string
+			fmt.Fscanln(in, &input)
+			pending <- input
+		}(e.stdin())
+		r.pending = pending
+	}
+	select {
+	case input := <-r.pending:
+		r.pending = nil
+		return input, true
+	case <-e.interrupted():
+		return "", false
+	}
+}
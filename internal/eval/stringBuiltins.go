@@ -11,7 +11,7 @@ import (
 )
 
 func getValue(self *env.Env) (string, error) {
-	valSym, ok := self.Symbols["value"]
+	valSym, ok := self.Get("value")
 	if !ok {
 		return "", errors.New("Struct instance does not have a 'value' field")
 	}
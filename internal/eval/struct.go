@@ -7,6 +7,10 @@ import (
 	"lang/internal/parser"
 )
 
+// unsetFieldType marks fields declared without a default value until
+// something is assigned to them.
+const unsetFieldType = "unset"
+
 func (e *Evaluator) evalStructDef(stmt *parser.StructDefNode) any {
 	if e.currentEnv.SymbolExists(stmt.Name) {
 		e.GenError(fmt.Sprintf(
@@ -16,17 +20,36 @@ func (e *Evaluator) evalStructDef(stmt *parser.StructDefNode) any {
 	structEnv := env.NewEnv(e.currentEnv, stmt.Name)
 
 	for _, field := range stmt.Fields {
+		if !field.IsPublic {
+			structEnv.SetPrivate(field.Name)
+		}
+		if field.IsStatic {
+			structEnv.SetStatic(field.Name)
+		} else {
+			structEnv.Fields = append(structEnv.Fields, field.Name)
+		}
 		if field.Value != nil {
 			value := e.EvalNode(field.Value)
-			structEnv.AddVarSymbol(
+			if value == nil {
+				return nil
+			}
+			value, ok := e.checkType(field.Type, value,
+				fmt.Sprintf("field '%s' of class '%s'", field.Name, stmt.Name),
+				field.Position)
+			if !ok {
+				return nil
+			}
+			structEnv.AddTypedVarSymbol(
 				field.Name,
+				field.Type,
 				e.resolveType(value, stmt.Position),
 				value)
 			continue
 		}
-		structEnv.AddVarSymbol(
+		structEnv.AddTypedVarSymbol(
 			field.Name,
-			"nil",
+			field.Type,
+			unsetFieldType,
 			core.NilValue{})
 	}
 
@@ -59,6 +82,13 @@ func (e *Evaluator) evalStructMethodDef(stmt *parser.StructMethodDef) any {
 			stmt.MethodName, stmt.StructName), stmt.Position)
 		return nil
 	}
+	annotate(structEnv.Symbol(stmt.MethodName), stmt)
+	if !stmt.IsPub {
+		structEnv.SetPrivate(stmt.MethodName)
+	}
+	if stmt.IsStatic {
+		structEnv.SetStatic(stmt.MethodName)
+	}
 
 	return structEnv
 }
@@ -73,14 +103,20 @@ func (e *Evaluator) evalStructInit(stmt *parser.StructInitNode) any {
 		return nil
 	}
 
-	instanceEnv := env.NewEnv(structSym.Environment, stmt.Name)
-
-	for fieldName, fieldSym := range structSym.Environment.Symbols {
-		if varSym, ok := fieldSym.(*env.VarSymbol); ok {
-			instanceEnv.AddVarSymbol(fieldName, varSym.Type(), varSym.Value())
+	if stmt.IsCall {
+		args := make([]any, len(stmt.Args))
+		for i, arg := range stmt.Args {
+			args[i] = e.EvalNode(arg)
+			if args[i] == nil {
+				return nil
+			}
 		}
+		return e.constructInstance(structSym, args, stmt.Position)
 	}
 
+	instanceEnv := newInstance(structSym)
+	assigned := make(map[string]bool)
+
 	for _, fieldAssign := range stmt.InitFields {
 		assign, ok := fieldAssign.(*parser.AssignmentNode)
 		if !ok {
@@ -89,28 +125,112 @@ func (e *Evaluator) evalStructInit(stmt *parser.StructInitNode) any {
 				stmt.Position)
 			return nil
 		}
-		val := e.EvalNode(assign.Value)
 		name, ok := assign.Name.(*parser.IdentifierNode)
+		if !ok {
+			e.GenError(
+				"Struct field assignment must use an identifier",
+				stmt.Position,
+			)
+			return nil
+		}
 		if !instanceEnv.SymbolExistsInCurrent(name.Name) {
 			e.GenError(fmt.Sprintf(
 				"Field '%s' is not defined in struct '%s'",
 				name.Name,
 				stmt.Name),
-				assign.Position)
+				stmt.Position)
+			return nil
+		}
+		if assigned[name.Name] {
+			e.GenError(fmt.Sprintf(
+				"Field '%s' is set more than once",
+				name.Name),
+				stmt.Position)
 			return nil
 		}
+		assigned[name.Name] = true
 
+		val := e.EvalNode(assign.Value)
+		if val == nil {
+			return nil
+		}
+		val, ok = e.checkType(
+			declaredType(instanceEnv.Symbol(name.Name)), val,
+			fmt.Sprintf("field '%s' of class '%s'", name.Name, stmt.Name),
+			stmt.Position)
 		if !ok {
-			e.GenError(
-				"Struct field assignment must use an identifier",
-				stmt.Position,
-			)
 			return nil
 		}
 		instanceEnv.UpdateSymbol(name.Name,
 			val, e.resolveType(val, assign.Position))
 	}
 
+	if !e.checkFieldsSet(structSym, instanceEnv, "literal", stmt.Position) {
+		return nil
+	}
+	return instanceEnv
+}
+
+// constructInstance handles 'Name(args)' and 'new Name(args)': a fresh
+// instance is passed to the class 'init' method and every field has to
+// be set once it returns.
+func (e *Evaluator) constructInstance(
+	structSym *env.StructSymbol,
+	args []any,
+	pos parser.Position) any {
+
+	instanceEnv := newInstance(structSym)
+
+	if _, ok := structSym.Environment.Symbol("init").(*env.FuncSymbol); ok {
+		if e.evalStructMethodCall(instanceEnv, "init", args, pos) == nil {
+			return nil
+		}
+	} else if len(args) != 0 {
+		e.GenError(fmt.Sprintf(
+			"Class '%s' has no 'init' method, but %d args were passed",
+			structSym.TypeName, len(args)), pos)
+		return nil
+	}
+
+	if !e.checkFieldsSet(structSym, instanceEnv, "constructor", pos) {
+		return nil
+	}
+	return instanceEnv
+}
+
+// checkFieldsSet reports the first field of instanceEnv left unset by
+// the constructor or literal that built it.
+func (e *Evaluator) checkFieldsSet(
+	structSym *env.StructSymbol,
+	instanceEnv *env.Env,
+	by string,
+	pos parser.Position) bool {
+
+	for _, name := range structSym.Environment.Fields {
+		if fieldSym := instanceEnv.Symbol(name); fieldSym.Type() == unsetFieldType {
+			e.GenError(fmt.Sprintf(
+				"Field '%s' of class '%s' is not set by %s",
+				name, structSym.TypeName, by), pos)
+			return false
+		}
+	}
+	return true
+}
+
+// newInstance creates an instance env with a copy of the class fields.
+func newInstance(structSym *env.StructSymbol) *env.Env {
+	instanceEnv := env.NewEnv(structSym.Environment, structSym.TypeName)
+
+	structSym.Environment.Each(func(fieldName string, fieldSym core.Symbol) {
+		if structSym.Environment.IsStatic(fieldName) {
+			return
+		}
+		if varSym, ok := fieldSym.(*env.VarSymbol); ok {
+			instanceEnv.AddTypedVarSymbol(fieldName,
+				varSym.Declared(), varSym.Type(), varSym.Value())
+		}
+	})
+
 	return instanceEnv
 }
 
@@ -120,60 +240,104 @@ func (e *Evaluator) evalStructMethodCall(
 	args []any,
 	pos parser.Position) any {
 
+	method, scope, self := e.findMethod(self, methodName, pos)
+	if method == nil {
+		return nil
+	}
+	return e.invokeMethod(scope, self, method, methodName, args, pos, false)
+}
+
+// findMethod looks up a method of the instance self. It returns the env
+// its call env is nested in, and self unless the method is static.
+func (e *Evaluator) findMethod(
+	self *env.Env,
+	methodName string,
+	pos parser.Position) (*env.FuncSymbol, *env.Env, *env.Env) {
+
 	if self.Parent == nil {
 		e.GenError(fmt.Sprintf(
 			"Struct type environment for method '%s' not found",
 			methodName),
 			pos,
 		)
-		return nil
+		return nil, nil, nil
 	}
-	methodSym, ok := self.Parent.Symbols[methodName]
+	methodSym, ok := self.Parent.Get(methodName)
 	if !ok {
 		e.GenError(fmt.Sprintf(
 			"Method '%s' not found in struct",
 			methodName),
 			pos)
-		return nil
+		return nil, nil, nil
 	}
 	method, ok := methodSym.(*env.FuncSymbol)
 	if !ok {
 		e.GenError(fmt.Sprintf("'%s' is not a method", methodName), pos)
-		return nil
+		return nil, nil, nil
+	}
+
+	if self.Parent.IsStatic(methodName) {
+		return method, self.Parent, nil
 	}
+	return method, self, self
+}
+
+// invokeMethod runs a method body in a call env nested in scope, or
+// returns the call to be made by call when tail is set. self is nil for
+// static methods.
+func (e *Evaluator) invokeMethod(
+	scope *env.Env,
+	self *env.Env,
+	method *env.FuncSymbol,
+	methodName string,
+	args []any,
+	pos parser.Position,
+	tail bool) any {
 
 	if method.NativeFunc != nil {
+		for _, arg := range args {
+			if _, ok := arg.(namedArg); ok {
+				e.GenError(fmt.Sprintf(
+					"Method '%s' doesn't take named arguments", methodName), pos)
+				return nil
+			}
+		}
 		return method.NativeFunc(e, self, args, pos)
 	}
-
-	if len(args) != len(method.Params) {
-		e.GenError(fmt.Sprintf(
-			"Method '%s' expects %d args, got %d",
-			methodName,
-			len(method.Params),
-			len(args)), pos)
-		return nil
+	class := scope
+	if self != nil {
+		class = self.Parent
 	}
-	callEnv := env.NewEnv(self, "function")
-	callEnv.AddVarSymbol("self", self.Type, self)
-
-	for i, val := range args {
-		callEnv.AddVarSymbol(method.Params[i],
-			e.resolveType(val, method.Body.Position), val)
+	c := callee{
+		fn:      method,
+		name:    methodName,
+		scope:   scope,
+		self:    self,
+		envType: "function",
+		pos:     pos,
+		class:   class,
 	}
-	prevEnv := e.currentEnv
-	e.currentEnv = callEnv
-	result := e.evalFuncBlock(method.Body)
-	e.currentEnv = prevEnv
-	if ret, ok := result.(core.ReturnValue); ok {
-		return ret.Value
+	if tail {
+		return c.deferred(args)
 	}
-	return result
+	return e.call(c, args)
 }
 
 func (e *Evaluator) evalStructMemberAccess(stmt *parser.StructMethodCall) any {
+	tail := e.isTailCall(stmt)
+	// ClassName.member
+	if ident, ok := stmt.Caller.(*parser.IdentifierNode); ok {
+		sym := e.currentEnv.FindSymbol(ident.Name)
+		if structSym, ok := sym.(*env.StructSymbol); ok {
+			return e.evalStaticMemberAccess(structSym.Environment, stmt, tail)
+		}
+	}
+
 	// Evaluate caller expression, expecting a struct instance Env
 	callerValue := e.EvalNode(stmt.Caller)
+	if g, ok := callerValue.(*generator); ok {
+		return e.generatorMethod(g, stmt)
+	}
 
 	instanceEnv, ok := callerValue.(*env.Env)
 	if !ok {
@@ -183,10 +347,17 @@ func (e *Evaluator) evalStructMemberAccess(stmt *parser.StructMethodCall) any {
 		return nil
 	}
 
+	if !e.checkMemberAccess(instanceEnv, instanceEnv.Parent, stmt.MethodName, stmt.Position) {
+		return nil
+	}
+
 	if stmt.IsField {
-		if fieldSym, ok := instanceEnv.Symbols[stmt.MethodName]; ok {
+		if fieldSym, ok := instanceEnv.Get(stmt.MethodName); ok {
 			return fieldSym.Value()
 		}
+		if class := instanceEnv.Parent; class != nil && class.IsStatic(stmt.MethodName) {
+			return class.Symbol(stmt.MethodName).Value()
+		}
 		e.GenError(fmt.Sprintf("Field '%s' not found in struct instance",
 			stmt.MethodName), stmt.Position)
 		return nil
@@ -198,6 +369,66 @@ func (e *Evaluator) evalStructMemberAccess(stmt *parser.StructMethodCall) any {
 		argValues[i] = e.EvalNode(arg)
 	}
 
-	return e.evalStructMethodCall(
-		instanceEnv, stmt.MethodName, argValues, stmt.Position)
+	method, scope, self := e.findMethod(instanceEnv, stmt.MethodName, stmt.Position)
+	if method == nil {
+		return nil
+	}
+	return e.invokeMethod(
+		scope, self, method, stmt.MethodName, argValues, stmt.Position, tail)
+}
+
+func (e *Evaluator) evalStaticMemberAccess(
+	classEnv *env.Env,
+	stmt *parser.StructMethodCall,
+	tail bool) any {
+
+	if !e.checkMemberAccess(nil, classEnv, stmt.MethodName, stmt.Position) {
+		return nil
+	}
+	sym, ok := classEnv.Get(stmt.MethodName)
+	if !ok || !classEnv.IsStatic(stmt.MethodName) {
+		e.GenError(fmt.Sprintf(
+			"'%s' is not a static member of class '%s'",
+			stmt.MethodName, classEnv.Type), stmt.Position)
+		return nil
+	}
+
+	if stmt.IsField {
+		return sym.Value()
+	}
+
+	method, ok := sym.(*env.FuncSymbol)
+	if !ok {
+		e.GenError(fmt.Sprintf("'%s' is not a method", stmt.MethodName),
+			stmt.Position)
+		return nil
+	}
+
+	argValues := make([]any, len(stmt.Args))
+	for i, arg := range stmt.Args {
+		argValues[i] = e.EvalNode(arg)
+	}
+
+	return e.invokeMethod(
+		classEnv, nil, method, stmt.MethodName, argValues, stmt.Position, tail)
+}
+
+// checkMemberAccess only lets private members be reached from inside
+// the methods of the class itself, through self when inst, the instance
+// they're reached through, isn't nil.
+func (e *Evaluator) checkMemberAccess(
+	inst *env.Env,
+	class *env.Env,
+	name string,
+	pos parser.Position) bool {
+
+	if class == nil || !class.IsPrivate(name) {
+		return true
+	}
+	if frame := e.currentFrame(); frame.class == class && (inst == nil || inst == frame.self) {
+		return true
+	}
+	e.GenError(fmt.Sprintf(
+		"'%s' is private in class '%s'", name, class.Type), pos)
+	return false
 }
@@ -0,0 +1,206 @@
+package eval
+
+import (
+	"fmt"
+	"lang/internal/core"
+	"lang/internal/env"
+	"lang/internal/parser"
+	"sync"
+	"time"
+)
+
+// timer is a callback scheduled by setTimeout or setInterval.
+type timer struct {
+	id   int
+	name string
+	fn   *env.FuncSymbol
+	due  time.Time
+	// zero for setTimeout
+	interval time.Duration
+	pos      parser.Position
+}
+
+// eventLoop holds the pending timers. It is shared with the evaluators
+// of spawned tasks, but callbacks only ever run on the main evaluator.
+type eventLoop struct {
+	mu     sync.Mutex
+	timers map[int]*timer
+	lastID int
+}
+
+func newEventLoop() *eventLoop {
+	return &eventLoop{timers: make(map[int]*timer)}
+}
+
+func (l *eventLoop) add(t *timer) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lastID++
+	t.id = l.lastID
+	l.timers[t.id] = t
+	return t.id
+}
+
+func (l *eventLoop) remove(id int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := l.timers[id]
+	delete(l.timers, id)
+	return ok
+}
+
+// earliest returns the timer due first, nil when none are pending.
+func (l *eventLoop) earliest() *timer {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var first *timer
+	for _, t := range l.timers {
+		if first == nil || t.due.Before(first.due) ||
+			t.due.Equal(first.due) && t.id < first.id {
+			first = t
+		}
+	}
+	return first
+}
+
+// runEventLoop runs timer callbacks in the order they are due until
+// none are left, so the program stays alive while timers are pending.
+// Tasks are waited for first as they may still schedule timers.
+func (e *Evaluator) runEventLoop() bool {
+	for {
+		if !e.waitTasks() {
+			return false
+		}
+		t := e.loop.earliest()
+		if t == nil {
+			return true
+		}
+		if !e.pause(time.Until(t.due)) {
+			return false
+		}
+
+		if t.interval > 0 {
+			t.due = t.due.Add(t.interval)
+		} else {
+			e.loop.remove(t.id)
+		}
+		errs := len(e.Errors)
+		e.callFunction(t.fn, t.name, nil, t.pos)
+		if len(e.Errors) > errs || e.halted() {
+			e.loop.remove(t.id)
+			return false
+		}
+	}
+}
+
+// evalDelay evaluates a duration in milliseconds.
+func (e *Evaluator) evalDelay(name string, arg parser.Node, pos parser.Position) (time.Duration, bool) {
+	value := e.EvalNode(arg)
+	if value == nil {
+		return 0, false
+	}
+	var ms float64
+	switch v := unwrapBuiltinValue(value).(type) {
+	case int:
+		ms = float64(v)
+	case float64:
+		ms = v
+	default:
+		e.GenError(fmt.Sprintf(
+			"%s: expects milliseconds as a number, got %s",
+			name, e.resolveType(value, pos)), pos)
+		return 0, false
+	}
+	if ms < 0 {
+		e.GenError(fmt.Sprintf("%s: delay can't be negative", name), pos)
+		return 0, false
+	}
+	return time.Duration(ms * float64(time.Millisecond)), true
+}
+
+// sleep(ms) blocks the current evaluator, timers don't fire meanwhile.
+func builtinSleep(e *Evaluator, args []parser.Node, pos parser.Position) any {
+	if len(args) != 1 {
+		e.GenError("sleep: expects 1 argument", pos)
+		return nil
+	}
+	d, ok := e.evalDelay("sleep", args[0], pos)
+	if !ok {
+		return nil
+	}
+	if !e.pause(d) {
+		return nil
+	}
+	return core.NilValue{}
+}
+
+func builtinSetTimeout(e *Evaluator, args []parser.Node, pos parser.Position) any {
+	return e.schedule("setTimeout", args, pos, false)
+}
+
+func builtinSetInterval(e *Evaluator, args []parser.Node, pos parser.Position) any {
+	return e.schedule("setInterval", args, pos, true)
+}
+
+// schedule implements setTimeout(fn, ms) and setInterval(fn, ms). fn is
+// called without arguments once the program's statements have run, and
+// the returned id can be passed to clearTimer.
+func (e *Evaluator) schedule(
+	name string,
+	args []parser.Node,
+	pos parser.Position,
+	repeat bool) any {
+
+	if len(args) != 2 {
+		e.GenError(fmt.Sprintf("%s: expects a function and a delay", name), pos)
+		return nil
+	}
+	value := e.EvalNode(args[0])
+	if value == nil {
+		return nil
+	}
+	fn, ok := value.(*env.FuncSymbol)
+	if !ok || fn.NativeFunc != nil {
+		e.GenError(fmt.Sprintf(
+			"%s: expects a function, got %s", name, e.typeName(value)), pos)
+		return nil
+	}
+	d, ok := e.evalDelay(name, args[1], pos)
+	if !ok {
+		return nil
+	}
+	if repeat && d == 0 {
+		e.GenError("setInterval: interval must be positive", pos)
+		return nil
+	}
+
+	t := &timer{
+		name: args[0].String(),
+		fn:   fn,
+		due:  time.Now().Add(d),
+		pos:  pos,
+	}
+	if repeat {
+		t.interval = d
+	}
+	return e.loop.add(t)
+}
+
+// clearTimer(id) cancels a pending timer and reports whether it was.
+func builtinClearTimer(e *Evaluator, args []parser.Node, pos parser.Position) any {
+	if len(args) != 1 {
+		e.GenError("clearTimer: expects 1 argument", pos)
+		return nil
+	}
+	value := e.EvalNode(args[0])
+	if value == nil {
+		return nil
+	}
+	id, ok := unwrapBuiltinValue(value).(int)
+	if !ok {
+		e.GenError(fmt.Sprintf(
+			"clearTimer: expects a timer id, got %s", e.resolveType(value, pos)), pos)
+		return nil
+	}
+	return e.loop.remove(id)
+}
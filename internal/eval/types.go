@@ -0,0 +1,86 @@
+package eval
+
+import (
+	"fmt"
+	"lang/internal/check"
+	"lang/internal/env"
+	"lang/internal/parser"
+	"strings"
+)
+
+// conforms reports whether value can be stored where declared is
+// expected. An empty declared type accepts anything.
+func (e *Evaluator) conforms(declared string, value any) bool {
+	if declared == "" || declared == "any" {
+		return true
+	}
+	value = unwrapBuiltinValue(value)
+	if isNilValue(value) {
+		return check.Assignable(declared, "nil")
+	}
+	if elem, ok := strings.CutPrefix(declared, "[]"); ok {
+		if !isArray(value) {
+			return false
+		}
+		for i := 0; i < arrayLen(value); i++ {
+			if !e.conforms(elem, e.arrayGet(value, i)) {
+				return false
+			}
+		}
+		return true
+	}
+	switch v := value.(type) {
+	case int:
+		return declared == "int" || declared == "float"
+	case float64:
+		return declared == "float"
+	case string:
+		return declared == "string"
+	case bool:
+		return declared == "bool"
+	case *env.Env:
+		return v.Parent != nil && v.Parent.Type == declared
+	}
+	return declared == e.typeName(value)
+}
+
+// typeName names the type of a value in type errors.
+func (e *Evaluator) typeName(value any) string {
+	switch v := unwrapBuiltinValue(value).(type) {
+	case *env.FuncSymbol:
+		return "func"
+	case []any:
+		return "array"
+	default:
+		return e.resolveType(v, parser.Position{Row: -1, Column: -1})
+	}
+}
+
+// checkType reports a type error when value doesn't fit declared and
+// returns the value to store: ints are converted where a float is
+// declared.
+func (e *Evaluator) checkType(
+	declared string,
+	value any,
+	what string,
+	pos parser.Position) (any, bool) {
+
+	if !e.conforms(declared, value) {
+		e.GenError(fmt.Sprintf(
+			"Type error: cannot use %s as %s in %s",
+			e.typeName(value), declared, what), pos)
+		return nil, false
+	}
+	if i, ok := unwrapBuiltinValue(value).(int); ok && declared == "float" {
+		return float64(i), true
+	}
+	return value, true
+}
+
+// declaredType returns the annotation of a variable or field symbol.
+func declaredType(sym any) string {
+	if varSym, ok := sym.(*env.VarSymbol); ok {
+		return varSym.Declared()
+	}
+	return ""
+}
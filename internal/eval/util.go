@@ -10,6 +10,10 @@ import (
 )
 
 func (e *Evaluator) GenError(msg string, pos parser.Position) {
+	// failures caused by stopping the program aren't errors of their own
+	if e.halted() {
+		return
+	}
 	e.Errors = append(
 		e.Errors, errors.New(fmt.Sprintf(
 			"%d, %d: %s",
@@ -34,8 +38,20 @@ func (e *Evaluator) resolveType(value any, pos parser.Position) string {
 		return v.Type
 	case []any:
 		return "[]"
+	case []int:
+		return "[]int"
+	case []float64:
+		return "[]float"
 	case core.NilValue:
 		return "nil"
+	case core.Range:
+		return "range"
+	case *generator:
+		return "generator"
+	case *task:
+		return "task"
+	case *channel:
+		return "channel"
 	default:
 		e.GenError(fmt.Sprintf("Unknown type '%v'", v), pos)
 		return ""
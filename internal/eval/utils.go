@@ -7,7 +7,7 @@ func unwrapBuiltinValue(v any) any {
         if instEnv.Parent != nil {
             pName := instEnv.Parent.Type
             if pName == "string" || pName == "int" || pName == "float" {
-                if valueSym, ok := instEnv.Symbols["value"]; ok {
+                if valueSym, ok := instEnv.Get("value"); ok {
                     return valueSym.Value()
                 }
             }
@@ -5,9 +5,14 @@ import (
 	"lang/internal/core"
 	"lang/internal/env"
 	"lang/internal/parser"
+	"slices"
+	"strings"
 )
 
 func (e *Evaluator) evalVarDef(stmt *parser.VarDefNode) any {
+	if stmt.Pattern != nil {
+		return e.evalDestructuringDef(stmt)
+	}
 	if e.currentEnv.SymbolExists(stmt.Name) {
 		e.GenError(fmt.Sprintf(
 			"Var '%s' already exists", stmt.Name),
@@ -15,8 +20,40 @@ func (e *Evaluator) evalVarDef(stmt *parser.VarDefNode) any {
 		return nil
 	}
 	value := e.EvalNode(stmt.Value)
+	if value == nil {
+		return nil
+	}
+	if stmt.Type != "" {
+		var ok bool
+		value, ok = e.checkType(stmt.Type, value,
+			fmt.Sprintf("definition of '%s'", stmt.Name), stmt.Position)
+		if !ok {
+			return nil
+		}
+	}
 	var_type := e.resolveType(value, stmt.Position)
-	e.currentEnv.AddVarSymbol(stmt.Name, var_type, value)
+	e.currentEnv.AddTypedVarSymbol(stmt.Name, stmt.Type, var_type, value)
+	return value
+}
+
+// evalDestructuringDef defines every name of 'var (a, b) = value;'.
+func (e *Evaluator) evalDestructuringDef(stmt *parser.VarDefNode) any {
+	names := append(slices.Clone(stmt.Pattern.Names), stmt.Pattern.Rest)
+	for _, name := range names {
+		if name != "" && name != "_" && e.currentEnv.SymbolExists(name) {
+			e.GenError(fmt.Sprintf(
+				"Var '%s' already exists", name),
+				stmt.Position)
+			return nil
+		}
+	}
+	value := e.EvalNode(stmt.Value)
+	if value == nil {
+		return nil
+	}
+	if !e.destructure(e.currentEnv, stmt.Pattern, value, stmt.Position) {
+		return nil
+	}
 	return value
 }
 
@@ -24,8 +61,19 @@ func (e *Evaluator) evalNil(stmt *parser.NilNode) any {
 	return core.NilValue{}
 }
 
+// lookup finds the symbol id refers to and the env defining it, by slot
+// when the resolver worked it out.
+func (e *Evaluator) lookup(id *parser.IdentifierNode) (core.Symbol, *env.Env) {
+	if id.Resolved {
+		if sym, owner, ok := e.currentEnv.Slot(id.Depth, id.Slot, id.Name); ok {
+			return sym, owner
+		}
+	}
+	return e.currentEnv.Lookup(id.Name)
+}
+
 func (e *Evaluator) evalIdentifier(id *parser.IdentifierNode) any {
-	i := e.currentEnv.FindSymbol(id.Name)
+	i, _ := e.lookup(id)
 	if i == nil {
 		e.GenError(fmt.Sprintf(
 			"Unknown identifier '%s'", id.Name),
@@ -42,7 +90,7 @@ func (e *Evaluator) evalAssignment(a *parser.AssignmentNode) any {
 	switch target := a.Name.(type) {
 	case *parser.IdentifierNode:
 		{
-			if !e.currentEnv.SymbolExists(target.Name) {
+			if existing, _ := e.lookup(target); existing == nil {
 				e.GenError(fmt.Sprintf(
 					"Variable '%s' does not exist",
 					target.Name),
@@ -51,7 +99,7 @@ func (e *Evaluator) evalAssignment(a *parser.AssignmentNode) any {
 			}
 
 			value := e.EvalNode(a.Value)
-			existing := e.currentEnv.FindSymbol(target.Name)
+			existing, owner := e.lookup(target)
 			if existing == nil {
 				e.GenError(fmt.Sprintf(
 					"Variable '%s' not found", target.Name),
@@ -70,60 +118,20 @@ func (e *Evaluator) evalAssignment(a *parser.AssignmentNode) any {
 				return nil
 			}
 
-			currentVal := unwrapBuiltinValue(sym.Value())
-
-			switch a.Op {
-			case "+=":
-				switch curr := currentVal.(type) {
-				case int:
-					if val, ok := value.(int); ok {
-						e.currentEnv.UpdateSymbol(target.Name, curr+val, "int")
-						return curr + val
-					}
-				case float64:
-					if val, ok := value.(float64); ok {
-						e.currentEnv.UpdateSymbol(target.Name, curr+val, "int")
-						return curr + val
-					}
-				case string:
-					if val, ok := value.(string); ok {
-						st := e.createString(curr + val)
-						e.currentEnv.UpdateSymbol(target.Name, st, "string")
-						return st
-					}
-				}
-				e.GenError(fmt.Sprintf(
-					"Unsupported types for '+=': %T and %T", currentVal, value),
-					target.Position,
-				)
+			newVal := e.applyAssignOp(a.Op, sym.Value(), value, target.Position)
+			if newVal == nil {
 				return nil
-
-			case "-=":
-				curr, ok1 := currentVal.(int)
-				val, ok2 := value.(int)
-				if ok1 && ok2 {
-					e.currentEnv.UpdateSymbol(target.Name, curr-val, "int")
-					return curr - val
+			}
+			if declared := declaredType(sym); declared != "" {
+				newVal, ok = e.checkType(declared, newVal,
+					fmt.Sprintf("assignment to '%s'", target.Name), a.Position)
+				if !ok {
+					return nil
 				}
-				e.GenError(fmt.Sprintf(
-					"Unsupported types for '-=': %T and %T", currentVal, value),
-					target.Position,
-				)
-				return nil
-
-			case "=":
-				e.currentEnv.UpdateSymbol(target.Name,
-					value, e.resolveType(value, target.Position))
-				return value
-
-			default:
-				e.GenError(fmt.Sprintf(
-					"Unsupported assignment operator: '%s'", a.Op),
-					target.Position,
-				)
-				return nil
 			}
-
+			owner.UpdateInCurrent(target.Name,
+				newVal, e.resolveType(newVal, target.Position))
+			return newVal
 		}
 	case *parser.StructMethodCall:
 		{
@@ -145,6 +153,24 @@ func (e *Evaluator) evalAssignment(a *parser.AssignmentNode) any {
 				return nil
 			}
 			sym := e.currentEnv.FindSymbol(callerIdent.Name)
+			if structSym, ok := sym.(*env.StructSymbol); ok {
+				// ClassName.field = ...
+				classEnv := structSym.Environment
+				if !e.checkMemberAccess(nil, classEnv, target.MethodName, target.Position) {
+					return nil
+				}
+				if !classEnv.SymbolExistsInCurrent(target.MethodName) ||
+					!classEnv.IsStatic(target.MethodName) {
+					e.GenError(fmt.Sprintf(
+						"'%s' is not a static field of class '%s'",
+						target.MethodName,
+						callerIdent.Name),
+						target.Position,
+					)
+					return nil
+				}
+				return e.assignField(classEnv, target.MethodName, a)
+			}
 			varSym, ok := sym.(*env.VarSymbol)
 			if !ok {
 				e.GenError(fmt.Sprintf(
@@ -161,23 +187,43 @@ func (e *Evaluator) evalAssignment(a *parser.AssignmentNode) any {
 				)
 				return nil
 			}
-			if !instanceEnv.SymbolExistsInCurrent(target.MethodName) {
-				e.GenError(fmt.Sprintf(
-					"Field '%s' does not exist in struct '%s'",
-					target.MethodName,
-					callerIdent.Name),
-					target.Position,
-				)
+			class := instanceEnv.Parent
+			if !e.checkMemberAccess(instanceEnv, class, target.MethodName, target.Position) {
 				return nil
 			}
-			value := e.EvalNode(a.Value)
-			instanceEnv.UpdateSymbol(target.MethodName,
-				value, e.resolveType(value, target.Position))
-			return value
-
+			if instanceEnv.SymbolExistsInCurrent(target.MethodName) {
+				return e.assignField(instanceEnv, target.MethodName, a)
+			}
+			if class != nil && class.IsStatic(target.MethodName) {
+				return e.assignField(class, target.MethodName, a)
+			}
+			e.GenError(fmt.Sprintf(
+				"Field '%s' does not exist in struct '%s'",
+				target.MethodName,
+				callerIdent.Name),
+				target.Position,
+			)
+			return nil
 		}
 	case *parser.ArrayAccessNode:
 		{
+			container := e.EvalNode(target.Target)
+			if container == nil {
+				return nil
+			}
+			if inst, ok := operatorMethod(container, "setIndex"); ok {
+				index := unwrapBuiltinValue(e.EvalNode(target.Index))
+				value := e.EvalNode(a.Value)
+				if index == nil || value == nil {
+					return nil
+				}
+				if e.invokeOperator(inst, "setIndex",
+					[]any{index, value}, target.Position) == nil {
+					return nil
+				}
+				return value
+			}
+
 			// Unwrap the identifier
 			arrNameNode := target.Target
 			indexValue := e.EvalNode(target.Index)
@@ -188,6 +234,9 @@ func (e *Evaluator) evalAssignment(a *parser.AssignmentNode) any {
 				)
 				return nil
 			}
+			if _, ok := arrNameNode.(*parser.ArrayAccessNode); ok {
+				return e.assignNestedElement(container, indexInt, a.Value, target.Position)
+			}
 			var name string
 			switch val := arrNameNode.(type) {
 			case *parser.IdentifierNode:
@@ -216,14 +265,16 @@ func (e *Evaluator) evalAssignment(a *parser.AssignmentNode) any {
 				return nil
 			}
 
-			var arr []any
+			var arr any
 			switch v := unwrapBuiltinValue(varSym.Value()).(type) {
-			case []any:
+			case []any, []int, []float64:
 				arr = v
 			case string:
+				var chars []any
 				for i := 0; i < len(v); i++ {
-					arr = append(arr, v[i])
+					chars = append(chars, v[i])
 				}
+				arr = chars
 			default:
 				{
 					e.GenError(fmt.Sprintf(
@@ -242,17 +293,32 @@ func (e *Evaluator) evalAssignment(a *parser.AssignmentNode) any {
 			}
 
 			value := unwrapBuiltinValue(e.EvalNode(a.Value))
-			if indexInt < len(arr) {
+			if elem, ok := strings.CutPrefix(varSym.Declared(), "[]"); ok {
+				value, ok = e.checkType(elem, value,
+					fmt.Sprintf("element of '%s'", name), a.Position)
+				if !ok {
+					return nil
+				}
+			}
+			if indexInt < arrayLen(arr) {
 				// Normal case: overwrite
-				arr[indexInt] = value
-			} else if indexInt == len(arr) {
+				if err := e.arraySet(arr, indexInt, value); err != nil {
+					e.GenError(err.Error(), target.Position)
+					return nil
+				}
+			} else if indexInt == arrayLen(arr) {
 				// Append/grow array by one position
-				arr = append(arr, value)
+				grown, err := e.arrayAppend(arr, value)
+				if err != nil {
+					e.GenError(err.Error(), target.Position)
+					return nil
+				}
+				arr = grown
 			} else {
 				// Trying to set beyond the next element: error
 				e.GenError(fmt.Sprintf(
 					"Index %d is out of range. You can insert only at len(arr)=%d",
-					indexInt, len(arr)),
+					indexInt, arrayLen(arr)),
 					target.Position,
 				)
 				return nil
@@ -268,3 +334,95 @@ func (e *Evaluator) evalAssignment(a *parser.AssignmentNode) any {
 		return nil
 	}
 }
+
+// assignField stores into a field of an instance or class env.
+func (e *Evaluator) assignField(
+	fieldEnv *env.Env,
+	name string,
+	a *parser.AssignmentNode) any {
+
+	if enumEnv := enumOf(fieldEnv); enumEnv != nil {
+		e.GenError(fmt.Sprintf(
+			"Cannot assign to '%s' of enum '%s'", name, enumEnv.Type),
+			a.Position)
+		return nil
+	}
+	current := fieldEnv.Symbol(name).Value()
+	value := e.applyAssignOp(a.Op, current, e.EvalNode(a.Value), a.Position)
+	if value == nil {
+		return nil
+	}
+	value, ok := e.checkType(declaredType(fieldEnv.Symbol(name)), value,
+		fmt.Sprintf("assignment to field '%s'", name), a.Position)
+	if !ok {
+		return nil
+	}
+	fieldEnv.UpdateSymbol(name, value, e.resolveType(value, a.Position))
+	return value
+}
+
+// applyAssignOp computes the value stored by '=', '+=' and '-='.
+func (e *Evaluator) applyAssignOp(
+	op string,
+	current any,
+	value any,
+	pos parser.Position) any {
+
+	if value == nil {
+		return nil
+	}
+
+	switch op {
+	case "=":
+		return value
+	case "+=", "-=":
+		curr := unwrapBuiltinValue(current)
+		val := unwrapBuiltinValue(value)
+		switch c := curr.(type) {
+		case int:
+			switch v := val.(type) {
+			case int:
+				if op == "+=" {
+					return c + v
+				}
+				return c - v
+			case float64:
+				if op == "+=" {
+					return float64(c) + v
+				}
+				return float64(c) - v
+			}
+		case float64:
+			switch v := val.(type) {
+			case int:
+				if op == "+=" {
+					return c + float64(v)
+				}
+				return c - float64(v)
+			case float64:
+				if op == "+=" {
+					return c + v
+				}
+				return c - v
+			}
+		case string:
+			if v, ok := val.(string); ok && op == "+=" {
+				if !e.reserve(uint64(len(c)+len(v)), pos) {
+					return nil
+				}
+				return e.createString(c + v)
+			}
+		}
+		e.GenError(fmt.Sprintf(
+			"Unsupported types for '%s': %T and %T", op, curr, val),
+			pos,
+		)
+		return nil
+	}
+
+	e.GenError(fmt.Sprintf(
+		"Unsupported assignment operator: '%s'", op),
+		pos,
+	)
+	return nil
+}
@@ -3,6 +3,7 @@ package lexer
 import (
 	"fmt"
 	"lang/internal/token"
+	"strconv"
 	"unicode"
 )
 
@@ -42,7 +43,7 @@ func (l *Lexer) Read(s string) ([]*token.Token, error) {
 		startColumn := l.currentColumn
 
 		// Identifiers/Keywords
-		if unicode.IsLetter(ch) {
+		if unicode.IsLetter(ch) || ch == '_' {
 			start := i
 			for i < length && (unicode.IsLetter(l.source[i]) || unicode.IsDigit(l.source[i]) || l.source[i] == '_') {
 				i++
@@ -107,23 +108,15 @@ func (l *Lexer) Read(s string) ([]*token.Token, error) {
 					continue
 				}
 
-				if c == '\\' && i+1 < length {
-					nextChar := l.source[i+1]
-					switch nextChar {
-					case '"', '\\', 'n', 't', 'r':
-						if nextChar == 'n' {
-							str = append(str, '\n')
-						} else if nextChar == 't' {
-							str = append(str, '\t')
-						} else if nextChar == 'r' {
-							str = append(str, '\r')
-						} else {
-							str = append(str, nextChar)
-						}
-						i += 2
-						l.currentColumn += 2
-						continue
+				if c == '\\' {
+					value, width, ok := unescape(l.source[i:])
+					if !ok {
+						return tokens, fmt.Errorf("invalid escape sequence at line %d, column %d", l.currentLine, l.currentColumn)
 					}
+					str = append(str, value)
+					i += width
+					l.currentColumn += width
+					continue
 				}
 
 				str = append(str, c)
@@ -234,6 +227,12 @@ func (l *Lexer) Read(s string) ([]*token.Token, error) {
 				l.currentColumn += 2
 				continue
 			}
+			if l.Next(i) == '>' {
+				tokens = append(tokens, l.genTokenAtPosition("=>", token.FatArrow, l.currentLine, startColumn))
+				i++
+				l.currentColumn += 2
+				continue
+			}
 			tokens = append(tokens, l.genTokenAtPosition("=", token.Assign, l.currentLine, startColumn))
 			l.currentColumn++
 		case '&':
@@ -276,6 +275,18 @@ func (l *Lexer) Read(s string) ([]*token.Token, error) {
 			tokens = append(tokens, l.genTokenAtPosition(",", token.Comma, l.currentLine, startColumn))
 			l.currentColumn++
 		case '.':
+			if l.Next(i) == '.' && l.Next(i+1) == '.' {
+				tokens = append(tokens, l.genTokenAtPosition("...", token.Ellipsis, l.currentLine, startColumn))
+				i += 2
+				l.currentColumn += 3
+				continue
+			}
+			if l.Next(i) == '.' {
+				tokens = append(tokens, l.genTokenAtPosition("..", token.DotDot, l.currentLine, startColumn))
+				i++
+				l.currentColumn += 2
+				continue
+			}
 			tokens = append(tokens, l.genTokenAtPosition(".", token.Dot, l.currentLine, startColumn))
 			l.currentColumn++
 		case '(':
@@ -297,8 +308,7 @@ func (l *Lexer) Read(s string) ([]*token.Token, error) {
 			tokens = append(tokens, l.genTokenAtPosition("]", token.RBrace, l.currentLine, startColumn))
 			l.currentColumn++
 		default:
-			fmt.Printf("Unknown character '%c' at line %d, column %d\n", ch, l.currentLine, l.currentColumn)
-			l.currentColumn++ // skip unknown char to avoid infinite loop
+			return tokens, fmt.Errorf("unknown character '%c' at line %d, column %d", ch, l.currentLine, l.currentColumn)
 		}
 	}
 
@@ -359,7 +369,40 @@ func (l *Lexer) getWordType(word string) token.TokenType {
 		return token.Public
 	case "nil":
 		return token.Nil
+	case "new":
+		return token.New
+	case "static":
+		return token.Static
+	case "enum":
+		return token.Enum
+	case "match", "switch":
+		return token.Match
+	case "break":
+		return token.Break
+	case "continue":
+		return token.Continue
+	case "yield":
+		return token.Yield
+	case "spawn":
+		return token.Spawn
+	case "do":
+		return token.Do
+	case "in":
+		return token.In
 	default:
 		return token.Identifier
 	}
 }
+
+// unescape decodes the escape sequence src starts with, as in a Go
+// string literal, and returns its value and length.
+func unescape(src []rune) (value rune, width int, ok bool) {
+	// the longest escape is \U and 8 hex digits
+	s := string(src[:min(len(src), 10)])
+	value, _, tail, err := strconv.UnquoteChar(s, '"')
+	if err != nil {
+		return 0, 0, false
+	}
+	// escapes are ASCII, so their bytes are runes
+	return value, len(s) - len(tail), true
+}
@@ -25,6 +25,7 @@ func (p *Parser) parseArray() *ArrayNode {
         p.advance()
     } else {
         p.genError("Expected ']' at end of array literal")
+        return nil
     }
 
     return &ArrayNode{
@@ -46,14 +47,16 @@ func (p *Parser) parseArrayAccess(node Node) *ArrayAccessNode {
             return nil
         }
         p.advance() // skip ']'
+        end := p.currentOrLastToken()
         retNode = &ArrayAccessNode{
 			Position: Position {
-				Row: p.currentToken().Line,
-				Column: p.currentToken().Column,
+				Row: end.Line,
+				Column: end.Column,
 			},
             Target: node, // The previous node (IdentifierNode or ArrayAccessNode)
             Index: index,
         }
+        node = retNode
     }
 
 	return retNode
@@ -0,0 +1,101 @@
+package parser
+
+import (
+	"fmt"
+	"lang/internal/token"
+)
+
+// isDestructureStart reports whether tok opens a destructuring pattern.
+func isDestructureStart(tok *token.Token) bool {
+	return tok != nil && (tok.TType == token.LParen ||
+		tok.TType == token.LBrace || tok.TType == token.LCurly)
+}
+
+// skipBrackets returns the index just past the bracket group opening at
+// Tokens[i].
+func (p *Parser) skipBrackets(i int) int {
+	depth := 0
+	for ; i < p.TokensLength; i++ {
+		switch p.Tokens[i].TType {
+		case token.LParen, token.LBrace, token.LCurly:
+			depth++
+		case token.RParen, token.RBrace, token.RCurly:
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return i
+}
+
+// parseDestructure parses '(a, b)', '[a, _, ...rest]' or '{x, y: py}'.
+func (p *Parser) parseDestructure() *Destructure {
+	open := p.currentToken()
+	closing := map[token.TokenType]token.TokenType{
+		token.LParen: token.RParen,
+		token.LBrace: token.RBrace,
+		token.LCurly: token.RCurly,
+	}[open.TType]
+	byField := open.TType == token.LCurly
+	p.advance()
+
+	pat := &Destructure{
+		Position: Position{
+			Row:    open.Line,
+			Column: open.Column,
+		},
+	}
+	for p.currentToken() != nil && p.currentToken().TType != closing {
+		if p.currentToken().TType == token.Comma {
+			p.advance()
+			continue
+		}
+		if pat.Rest != "" {
+			p.genError(fmt.Sprintf("'...%s' must be the last name", pat.Rest))
+			return nil
+		}
+		rest := p.currentToken().TType == token.Ellipsis
+		if rest {
+			if byField {
+				p.genError("'...' is only allowed when destructuring arrays")
+				return nil
+			}
+			p.advance()
+		}
+		tok := p.currentToken()
+		if tok == nil || tok.TType != token.Identifier {
+			p.genError("Expected name in destructuring pattern")
+			return nil
+		}
+		p.advance()
+		if rest {
+			pat.Rest = tok.Lexeme
+			continue
+		}
+
+		name := tok.Lexeme
+		if byField {
+			pat.Fields = append(pat.Fields, name)
+			if p.currentToken() != nil && p.currentToken().TType == token.Colon {
+				p.advance() // skip ':'
+				alias := p.currentToken()
+				if alias == nil || alias.TType != token.Identifier {
+					p.genError(fmt.Sprintf("Expected name for field '%s'", name))
+					return nil
+				}
+				name = alias.Lexeme
+				p.advance()
+			}
+		}
+		pat.Names = append(pat.Names, name)
+	}
+	if !p.expectAndAdvance(closing) {
+		return nil
+	}
+	if len(pat.Names) == 0 && pat.Rest == "" {
+		p.genError("Destructuring pattern needs at least one name")
+		return nil
+	}
+	return pat
+}
@@ -0,0 +1,63 @@
+package parser
+
+import "lang/internal/token"
+
+// parseEnumDef parses 'enum Name { A, B, C }'.
+func (p *Parser) parseEnumDef() *EnumDefNode {
+	p.advance() // skip 'enum'
+	nameTok := p.currentToken()
+	if nameTok == nil || nameTok.TType != token.Identifier {
+		p.genError("Expected enum name")
+		return nil
+	}
+	p.advance() // skip enum name
+
+	if p.currentToken() == nil || p.currentToken().TType != token.LCurly {
+		p.genError("Expected '{' after enum name")
+		return nil
+	}
+	p.advance() // skip '{'
+
+	var members []string
+	seen := make(map[string]bool)
+	for p.currentToken() != nil && p.currentToken().TType != token.RCurly {
+		memberTok := p.currentToken()
+		if memberTok.TType != token.Identifier {
+			p.genError("Expected enum member name")
+			return nil
+		}
+		if seen[memberTok.Lexeme] {
+			p.genError("Duplicate enum member '" + memberTok.Lexeme + "'")
+			return nil
+		}
+		seen[memberTok.Lexeme] = true
+		members = append(members, memberTok.Lexeme)
+		p.advance()
+
+		if p.currentToken() != nil && p.currentToken().TType == token.Comma {
+			p.advance()
+		} else if p.currentToken() != nil && p.currentToken().TType != token.RCurly {
+			p.genError("Expected ',' or '}' after enum member")
+			return nil
+		}
+	}
+
+	if p.currentToken() == nil {
+		p.genError("Expected '}' at end of enum")
+		return nil
+	}
+	if len(members) == 0 {
+		p.genError("Enum '" + nameTok.Lexeme + "' has no members")
+		return nil
+	}
+	p.advance() // skip '}'
+
+	return &EnumDefNode{
+		Position: Position{
+			Row:    nameTok.Line,
+			Column: nameTok.Column,
+		},
+		Name:    nameTok.Lexeme,
+		Members: members,
+	}
+}
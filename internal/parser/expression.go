@@ -6,6 +6,9 @@ import (
 )
 
 func (p *Parser) parseExpression(precedence int) Node {
+	if p.endOfInput("expression") {
+		return nil
+	}
 	tok := p.currentToken()
 	var left Node
 
@@ -25,6 +28,26 @@ func (p *Parser) parseExpression(precedence int) Node {
 			return nil
 		}
 		p.advance()
+	case token.LBrace:
+		left = p.parseArray()
+	case token.Match:
+		node := p.parseMatch()
+		if node == nil {
+			return nil
+		}
+		left = node
+	case token.New:
+		node := p.parseNew()
+		if node == nil {
+			return nil
+		}
+		left = node
+	case token.Spawn:
+		node := p.parseSpawn()
+		if node == nil {
+			return nil
+		}
+		left = node
 	case token.Nil:
 		p.advance()
 		return &NilNode{
@@ -36,19 +59,27 @@ func (p *Parser) parseExpression(precedence int) Node {
 	case token.Minus:
 		p.advance()
 		expr := p.parseExpression(100) // high precedence for unary minus
+		if expr == nil {
+			return nil
+		}
+		end := p.currentOrLastToken()
 		left = &UnaryOpNode{
 			Position: Position{
-				Row:    p.currentToken().Line,
-				Column: p.currentToken().Column,
+				Row:    end.Line,
+				Column: end.Column,
 			},
 			Op: "-", Expr: expr}
 	case token.Bang:
 		p.advance()
 		expr := p.parseExpression(100) // high precedence for unary ops
+		if expr == nil {
+			return nil
+		}
+		end := p.currentOrLastToken()
 		left = &UnaryOpNode{
 			Position: Position{
-				Row:    p.currentToken().Line,
-				Column: p.currentToken().Column,
+				Row:    end.Line,
+				Column: end.Column,
 			},
 			Op: "!", Expr: expr}
 	case token.PlusPlus, token.MinusMinus:
@@ -59,6 +90,9 @@ func (p *Parser) parseExpression(precedence int) Node {
 			op = "--"
 		}
 		p.advance()
+		if p.endOfInput(fmt.Sprintf("identifier after '%s'", op)) {
+			return nil
+		}
 
 		var expr Node
 		switch p.currentToken().TType {
@@ -68,11 +102,15 @@ func (p *Parser) parseExpression(precedence int) Node {
 			p.genError(fmt.Sprintf("expected identifier after '%s'", op))
 			return nil
 		}
+		if expr == nil {
+			return nil
+		}
 
+		end := p.currentOrLastToken()
 		left = &UnaryOpNode{
 			Position: Position{
-				Row:    p.currentToken().Line,
-				Column: p.currentToken().Column,
+				Row:    end.Line,
+				Column: end.Column,
 			},
 			Op:   op,
 			Expr: expr,
@@ -142,10 +180,25 @@ func (p *Parser) parseExpression(precedence int) Node {
 		op := next.TType
 		p.advance()
 		right := p.parseExpression(opPrec)
+		if right == nil {
+			return nil
+		}
+		if op == token.DotDot {
+			left = &RangeNode{
+				Position: Position{
+					Row:    next.Line,
+					Column: next.Column,
+				},
+				Start: left,
+				End:   right,
+			}
+			continue
+		}
+		end := p.currentOrLastToken()
 		left = &BinaryOpNode{
 			Position: Position{
-				Row:    p.currentToken().Line,
-				Column: p.currentToken().Column,
+				Row:    end.Line,
+				Column: end.Column,
 			},
 			Op:    tokenTypeToString(op),
 			Left:  left,
@@ -163,7 +216,7 @@ func precedenceOf(tok token.TokenType) int {
 		return 2
 	case token.Equals, token.NotEquals:
 		return 3
-	case token.Less, token.More, token.LessEq, token.MoreEq:
+	case token.Less, token.More, token.LessEq, token.MoreEq, token.DotDot:
 		return 4
 	case token.Plus, token.Minus:
 		return 5
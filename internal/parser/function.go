@@ -16,23 +16,24 @@ func (p *Parser) parseFuncDef() *FunctionDefNode {
 	name := nameTok.Lexeme
 
 	p.advance()
-	if p.currentToken().TType != token.LParen {
+	if !p.expect(token.LParen) {
 		p.genError(fmt.Sprintf(
 			"expected function parameters after function name, got %v", nameTok))
 		return nil
 	}
 	p.advance()
-	var params []string
-	for p.currentToken().TType != token.RParen {
-		if p.currentToken().TType == token.Comma {
-			p.advance()
-		}
-		params = append(params, p.currentToken().Lexeme)
-		p.advance()
+	params, ok := p.parseParams()
+	if !ok {
+		return nil
 	}
-	p.advance()
-	p.advance()
-	body := p.parseBlock()
+	returnType, ok := p.parseOptionalType()
+	if !ok {
+		return nil
+	}
+	if !p.expectAndAdvance(token.LCurly) {
+		return nil
+	}
+	body, isGenerator := p.parseFuncBody()
 
 	return &FunctionDefNode{
 		Position: Position {
@@ -40,8 +41,13 @@ func (p *Parser) parseFuncDef() *FunctionDefNode {
 			Column: nameTok.Column,
 		},
 		Name: name,
-		Parameters: params,
+		Parameters: params.Names,
+		ParamTypes: params.Types,
+		Defaults: params.Defaults,
+		Variadic: params.Variadic,
+		ReturnType: returnType,
 		Body: body,
+		IsGenerator: isGenerator,
 	}
 }
 
@@ -54,7 +60,11 @@ func (p *Parser) parseFuncCall(node Node) *FunctionCallNode {
 			p.advance()
 			continue
 		}
-		args = append(args, p.parseExpression(0))
+		arg := p.parseArg(func() Node { return p.parseExpression(0) })
+		if arg == nil {
+			return nil
+		}
+		args = append(args, arg)
 	}
 	if p.currentToken() != nil && p.currentToken().TType == token.RParen {
 		p.advance() // skip ')'
@@ -72,7 +82,35 @@ func (p *Parser) parseFuncCall(node Node) *FunctionCallNode {
 	}
 }
 
+// parseArg parses a call argument with parseValue, or 'name: value'
+// for a named argument.
+func (p *Parser) parseArg(parseValue func() Node) Node {
+	tok := p.currentToken()
+	next := p.nextToken()
+	if tok.TType != token.Identifier || next == nil || next.TType != token.Colon {
+		return parseValue()
+	}
+	p.advance() // skip name
+	p.advance() // skip ':'
+	value := parseValue()
+	if value == nil {
+		return nil
+	}
+	return &NamedArgNode{
+		Position: Position {
+			Row: tok.Line,
+			Column: tok.Column,
+		},
+		Name: tok.Lexeme,
+		Value: value,
+	}
+}
+
 func (p *Parser) parseBlock() *BlockNode {
+	if p.currentToken() == nil {
+		p.genError("Expected '}' at end of block")
+		return nil
+	}
 	body := &BlockNode{
 		Position: Position {
 			Row: p.currentToken().Line,
@@ -80,7 +118,11 @@ func (p *Parser) parseBlock() *BlockNode {
 		},
 	}
 
-	for p.currentToken().TType != token.RCurly {
+	for !p.expect(token.RCurly) {
+		if p.currentToken() == nil {
+			p.genError("Expected '}' at end of block")
+			return nil
+		}
 		stmt := p.parseStatement()
 		if stmt == nil {
 			return nil
@@ -0,0 +1,41 @@
+package parser
+
+import "lang/internal/token"
+
+// parseFuncBody parses a function or method body after '{' and reports
+// whether it contains 'yield', which makes it a generator.
+func (p *Parser) parseFuncBody() (*BlockNode, bool) {
+	outer := p.yields
+	yields := false
+	p.yields = &yields
+	body := p.parseBlock()
+	p.yields = outer
+	return body, yields
+}
+
+// parseYield parses 'yield value;' or 'yield;'.
+func (p *Parser) parseYield() *YieldNode {
+	tok := p.currentToken()
+	p.advance() // skip 'yield'
+	if p.yields == nil {
+		p.genError("'yield' outside of a function")
+		return nil
+	}
+	*p.yields = true
+
+	node := &YieldNode{
+		Position: Position{
+			Row:    tok.Line,
+			Column: tok.Column,
+		},
+	}
+	if p.currentToken() != nil && p.currentToken().TType != token.Semicolon {
+		if node.Value = p.parseExpression(0); node.Value == nil {
+			return nil
+		}
+	}
+	if !p.expectAndAdvance(token.Semicolon) {
+		return nil
+	}
+	return node
+}
@@ -1,11 +1,17 @@
 package parser
 
-import "lang/internal/token"
+import (
+	"fmt"
+	"lang/internal/token"
+)
 
 func (p *Parser) parseWhile() *WhileNode {
 	initTok := p.currentToken()
 	p.advance()
 
+	if p.endOfInput("'(' or '{' after 'while'") {
+		return nil
+	}
 	if p.currentToken().TType == token.LCurly {
 		// while {}
 		body := p.parseBlock()
@@ -23,12 +29,17 @@ func (p *Parser) parseWhile() *WhileNode {
 		p.advance()
 
 		cond := p.parseExpression(0)
-		if p.currentToken().TType != token.RParen {
+		if cond == nil {
+			return nil
+		}
+		if !p.expect(token.RParen) {
 			p.genError("Expected ')' after condition")
 			return nil
 		}
 		p.advance()
-		p.advance()
+		if !p.expectAndAdvance(token.LCurly) {
+			return nil
+		}
 
 		body := p.parseBlock()
 		return &WhileNode{
@@ -42,14 +53,21 @@ func (p *Parser) parseWhile() *WhileNode {
 	}
 }
 
-func (p *Parser) parseForLoop() *ForNode {
+func (p *Parser) parseForLoop() Node {
 	p.advance()
 	initToken := p.currentToken()
 	if !p.expectAndAdvance(token.LParen) {
 		return nil
 	}
+	if p.isForIn() {
+		node := p.parseForIn(initToken)
+		if node == nil {
+			return nil
+		}
+		return node
+	}
 	var Init *VarDefNode = nil
-	if p.currentToken().TType == token.Semicolon {
+	if p.expect(token.Semicolon) {
 		p.advance()
 	} else {
 		Init = p.parseVarDef()
@@ -58,7 +76,7 @@ func (p *Parser) parseForLoop() *ForNode {
 		}
 	}
 	var Condition Node = nil
-	if p.currentToken().TType == token.Semicolon {
+	if p.expect(token.Semicolon) {
 		p.advance()
 	} else {
 		Condition = p.parseExpression(0)
@@ -67,7 +85,7 @@ func (p *Parser) parseForLoop() *ForNode {
 		}
 	}
 	var Post Node = nil
-	if p.currentToken().TType == token.RParen {
+	if p.expect(token.RParen) {
 		p.advance()
 	} else {
 		Post = p.parseExpression(0)
@@ -83,15 +101,179 @@ func (p *Parser) parseForLoop() *ForNode {
 	body := p.parseBlock()
 
 	node := &ForNode{
-		Position {
+		Position: Position {
 			Row: initToken.Line,
 			Column: initToken.Column,
 		},
-		Init,
-		Condition,
-		Post,
-		body,
+		Init: Init,
+		Condition: Condition,
+		Post: Post,
+		Body: body,
 	}
 
 	return node
 }
+
+// parseLabeledLoop parses 'label: for (...) {}' and 'label: while ...'.
+func (p *Parser) parseLabeledLoop() Node {
+	label := p.currentToken().Lexeme
+	p.advance() // skip label
+	p.advance() // skip ':'
+
+	if p.endOfInput("loop after label") {
+		return nil
+	}
+	switch p.currentToken().TType {
+	case token.For, token.Foreach:
+		switch loop := p.parseForLoop().(type) {
+		case *ForNode:
+			loop.Label = label
+			return loop
+		case *ForInNode:
+			loop.Label = label
+			return loop
+		}
+		return nil
+	case token.While:
+		loop := p.parseWhile()
+		if loop == nil {
+			return nil
+		}
+		loop.Label = label
+		return loop
+	case token.Do:
+		loop := p.parseDoWhile()
+		if loop == nil {
+			return nil
+		}
+		loop.Label = label
+		return loop
+	}
+	p.genError(fmt.Sprintf("Expected loop after label '%s'", label))
+	return nil
+}
+
+// isForIn reports whether the loop header after '(' is 'name in',
+// 'var name in' or a destructuring pattern followed by 'in'.
+func (p *Parser) isForIn() bool {
+	i := p.pos
+	if i < p.TokensLength && p.Tokens[i].TType == token.Var {
+		i++
+	}
+	if i < p.TokensLength && isDestructureStart(p.Tokens[i]) {
+		i = p.skipBrackets(i)
+		return i < p.TokensLength && p.Tokens[i].TType == token.In
+	}
+	return i+1 < p.TokensLength &&
+		p.Tokens[i].TType == token.Identifier &&
+		p.Tokens[i+1].TType == token.In
+}
+
+// parseForIn parses the rest of 'for (i in 0..10) {}',
+// 'for (x in array) {}' and their 'foreach' spelling.
+func (p *Parser) parseForIn(initToken *token.Token) *ForInNode {
+	if p.currentToken().TType == token.Var {
+		p.advance()
+	}
+	var name string
+	var pattern *Destructure
+	if isDestructureStart(p.currentToken()) {
+		if pattern = p.parseDestructure(); pattern == nil {
+			return nil
+		}
+	} else {
+		name = p.currentToken().Lexeme
+		p.advance() // skip name
+	}
+	p.advance() // skip 'in'
+
+	iterable := p.parseExpression(0)
+	if iterable == nil {
+		return nil
+	}
+	if !p.expectAndAdvance(token.RParen) {
+		return nil
+	}
+	if !p.expectAndAdvance(token.LCurly) {
+		return nil
+	}
+	body := p.parseBlock()
+	if body == nil {
+		return nil
+	}
+
+	return &ForInNode{
+		Position: Position{
+			Row:    initToken.Line,
+			Column: initToken.Column,
+		},
+		Var:      name,
+		Pattern:  pattern,
+		Iterable: iterable,
+		Body:     body,
+	}
+}
+
+func (p *Parser) parseDoWhile() *DoWhileNode {
+	initTok := p.currentToken()
+	p.advance() // skip 'do'
+
+	if !p.expectAndAdvance(token.LCurly) {
+		return nil
+	}
+	body := p.parseBlock()
+	if body == nil {
+		return nil
+	}
+	if !p.expectAndAdvance(token.While) {
+		return nil
+	}
+	if !p.expectAndAdvance(token.LParen) {
+		return nil
+	}
+	cond := p.parseExpression(0)
+	if cond == nil {
+		return nil
+	}
+	if !p.expectAndAdvance(token.RParen) {
+		return nil
+	}
+	if !p.expectAndAdvance(token.Semicolon) {
+		return nil
+	}
+
+	return &DoWhileNode{
+		Position: Position{
+			Row:    initTok.Line,
+			Column: initTok.Column,
+		},
+		Body:      body,
+		Condition: cond,
+	}
+}
+
+// parseLoopJump parses 'break;', 'continue;' and their labeled forms.
+func (p *Parser) parseLoopJump() Node {
+	tok := p.currentToken()
+	p.advance() // skip 'break' / 'continue'
+
+	label := ""
+	if p.currentToken() != nil && p.currentToken().TType == token.Identifier {
+		label = p.currentToken().Lexeme
+		p.advance()
+	}
+	if p.currentToken() == nil || p.currentToken().TType != token.Semicolon {
+		p.genError(fmt.Sprintf("Expected ';' after '%s'", tok.Lexeme))
+		return nil
+	}
+	p.advance() // skip ';'
+
+	pos := Position{
+		Row:    tok.Line,
+		Column: tok.Column,
+	}
+	if tok.TType == token.Break {
+		return &BreakNode{Position: pos, Label: label}
+	}
+	return &ContinueNode{Position: pos, Label: label}
+}
@@ -0,0 +1,209 @@
+package parser
+
+import (
+	"fmt"
+	"lang/internal/token"
+)
+
+// parseMatch parses 'match (value) { 1, 2 => ..., _ => ... }'. Arm
+// bodies are either a block or a single expression. Arms that can never
+// run are reported as errors.
+func (p *Parser) parseMatch() *MatchNode {
+	initTok := p.currentToken()
+	p.advance() // skip 'match'
+
+	if !p.expectAndAdvance(token.LParen) {
+		return nil
+	}
+	subject := p.parseExpression(0)
+	if subject == nil {
+		return nil
+	}
+	if !p.expectAndAdvance(token.RParen) {
+		return nil
+	}
+	if !p.expectAndAdvance(token.LCurly) {
+		return nil
+	}
+
+	node := &MatchNode{
+		Position: Position{
+			Row:    initTok.Line,
+			Column: initTok.Column,
+		},
+		Subject: subject,
+	}
+	seen := make(map[string]bool)
+	hasWildcard := false
+
+	for p.currentToken() != nil && p.currentToken().TType != token.RCurly {
+		armTok := p.currentToken()
+		if hasWildcard {
+			p.genError("Unreachable match arm after '_'")
+			return nil
+		}
+		arm := &MatchArm{
+			Position: Position{
+				Row:    armTok.Line,
+				Column: armTok.Column,
+			},
+		}
+
+		for {
+			pattern := p.parsePattern()
+			if pattern == nil {
+				return nil
+			}
+			if _, ok := pattern.(*WildcardPattern); ok {
+				hasWildcard = true
+			}
+			if key, ok := patternKey(pattern); ok {
+				if seen[key] {
+					p.genError(fmt.Sprintf(
+						"Unreachable pattern '%s', it is already matched above",
+						pattern.String()))
+					return nil
+				}
+				seen[key] = true
+			}
+			arm.Patterns = append(arm.Patterns, pattern)
+
+			if p.currentToken() == nil || p.currentToken().TType != token.Comma {
+				break
+			}
+			p.advance() // skip ','
+		}
+
+		if !p.expectAndAdvance(token.FatArrow) {
+			return nil
+		}
+
+		if p.expect(token.LCurly) {
+			p.advance() // skip '{'
+			arm.Body = p.parseBlock()
+		} else {
+			arm.Body = p.parseExpression(0)
+		}
+		if arm.Body == nil {
+			return nil
+		}
+		node.Arms = append(node.Arms, arm)
+
+		if p.currentToken() != nil &&
+			(p.currentToken().TType == token.Comma ||
+				p.currentToken().TType == token.Semicolon) {
+			p.advance()
+		}
+	}
+
+	if p.currentToken() == nil {
+		p.genError("Expected '}' at end of match")
+		return nil
+	}
+	p.advance() // skip '}'
+
+	return node
+}
+
+func (p *Parser) parsePattern() Node {
+	if p.endOfInput("pattern") {
+		return nil
+	}
+	tok := p.currentToken()
+	pos := Position{
+		Row:    tok.Line,
+		Column: tok.Column,
+	}
+
+	if tok.TType == token.Identifier {
+		next := p.nextToken()
+		switch {
+		case tok.Lexeme == "_":
+			p.advance()
+			return &WildcardPattern{Position: pos}
+		case next != nil && next.TType == token.LCurly:
+			return p.parseStructPattern()
+		case next == nil || (next.TType != token.Dot &&
+			next.TType != token.LParen &&
+			next.TType != token.DotDot):
+			// a bare name is a type: 'int', 'string', 'Point', ...,
+			// or a variable when no type has that name
+			p.advance()
+			return &TypePattern{Position: pos, TypeName: tok.Lexeme}
+		}
+	}
+
+	value := p.parseExpression(0)
+	if value == nil {
+		return nil
+	}
+	if r, ok := value.(*RangeNode); ok {
+		return &RangePattern{Position: pos, Low: r.Start, High: r.End}
+	}
+	return &ValuePattern{Position: pos, Value: value}
+}
+
+// parseStructPattern parses 'Point{x: 0, y: _}'.
+func (p *Parser) parseStructPattern() *StructPattern {
+	nameTok := p.currentToken()
+	p.advance() // skip class name
+	p.advance() // skip '{'
+
+	node := &StructPattern{
+		Position: Position{
+			Row:    nameTok.Line,
+			Column: nameTok.Column,
+		},
+		TypeName: nameTok.Lexeme,
+	}
+	for p.currentToken() != nil && p.currentToken().TType != token.RCurly {
+		fieldTok := p.currentToken()
+		if fieldTok.TType != token.Identifier {
+			p.genError("Expected field name in class pattern")
+			return nil
+		}
+		p.advance()
+		if !p.expectAndAdvance(token.Colon) {
+			return nil
+		}
+		pattern := p.parsePattern()
+		if pattern == nil {
+			return nil
+		}
+		node.Fields = append(node.Fields, &FieldPattern{
+			Name:    fieldTok.Lexeme,
+			Pattern: pattern,
+		})
+		if p.currentToken() != nil && p.currentToken().TType == token.Comma {
+			p.advance()
+		}
+	}
+	if p.currentToken() == nil {
+		p.genError("Expected '}' at end of class pattern")
+		return nil
+	}
+	p.advance() // skip '}'
+
+	return node
+}
+
+// patternKey identifies literal and type patterns, so a repeated one can
+// be reported as unreachable.
+func patternKey(pattern Node) (string, bool) {
+	switch pat := pattern.(type) {
+	case *TypePattern:
+		return "type " + pat.TypeName, true
+	case *ValuePattern:
+		switch v := pat.Value.(type) {
+		case *LiteralNode:
+			return fmt.Sprintf("%T %v", v.Value, v.Value), true
+		case *TrueNode:
+			return "true", true
+		case *FalseNode:
+			return "false", true
+		case *NilNode:
+			return "nil", true
+		}
+	}
+	return "", false
+}
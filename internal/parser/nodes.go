@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 )
 
 type Position struct {
@@ -9,6 +10,11 @@ type Position struct {
 	Column int
 }
 
+// Pos lets any node embedding Position report where it starts.
+func (p Position) Pos() Position {
+	return p
+}
+
 type Node interface {
 	String() string
 }
@@ -18,6 +24,21 @@ type ProgramNode struct {
 	Nodes []Node // List of statements or declarations
 }
 
+// IsPrivateDecl reports whether a top level declaration is marked 'pri'.
+func IsPrivateDecl(node Node) bool {
+	switch n := node.(type) {
+	case *VarDefNode:
+		return n.IsPrivate
+	case *FunctionDefNode:
+		return n.IsPrivate
+	case *StructDefNode:
+		return n.IsPrivate
+	case *EnumDefNode:
+		return n.IsPrivate
+	}
+	return false
+}
+
 func (p ProgramNode) Find(name string) (Node, error) {
 	for _, stmt := range p.Nodes {
 		switch s := stmt.(type) {
@@ -29,6 +50,14 @@ func (p ProgramNode) Find(name string) (Node, error) {
 			if s.Name == name {
 				return s, nil
 			}
+		case *StructDefNode:
+			if s.Name == name {
+				return s, nil
+			}
+		case *EnumDefNode:
+			if s.Name == name {
+				return s, nil
+			}
 		}
 	}
 
@@ -82,6 +111,10 @@ func (f *FalseNode) String() string {
 type IdentifierNode struct {
 	Position
 	Name string
+	// set by internal/resolver for the locals of functions: the symbol
+	// is in slot Slot of the env Depth levels up
+	Resolved    bool
+	Depth, Slot int
 }
 
 func (i *IdentifierNode) String() string {
@@ -159,8 +192,20 @@ func (n *UnaryOpNode) String() string {
 
 type StructDefNode struct {
 	Position
-	Name   string
-	Fields []*StructField
+	Name      string
+	Fields    []*StructField
+	IsPrivate bool
+}
+
+type EnumDefNode struct {
+	Position
+	Name      string
+	Members   []string
+	IsPrivate bool
+}
+
+func (e *EnumDefNode) String() string {
+	return "enum " + e.Name + " { " + strings.Join(e.Members, ", ") + " }"
 }
 
 func (s *StructDefNode) String() string {
@@ -174,21 +219,30 @@ func (s *StructDefNode) String() string {
 	return str
 }
 
+// Either a literal 'Name { field: value }' or a constructor call
+// 'Name(args)' / 'new Name(args)' which runs the class 'init' method.
 type StructInitNode struct {
 	Position
 	Name       string
 	InitFields []Node
+	Args       []Node
+	IsCall     bool
 }
 
 func (s *StructInitNode) String() string {
+	if s.IsCall {
+		return fmt.Sprintf("new %s(%v)", s.Name, s.Args)
+	}
 	return fmt.Sprintf("%s - %v", s.Name, s.InitFields)
 }
 
 type StructField struct {
 	Position
 	Name     string
+	Type     string // "" when not annotated
 	Value    Node
 	IsPublic bool // 0 - private 1 - pub
+	IsStatic bool
 }
 
 func (s *StructField) String() string {
@@ -210,10 +264,17 @@ func (s *StructField) String() string {
 type StructMethodDef struct {
 	Position
 	IsPub      bool
+	IsStatic   bool
 	StructName string
 	MethodName string
 	Parameters []string
+	ParamTypes []string // "" for parameters without annotation
+	Defaults   []Node   // nil for parameters without a default value
+	Variadic   bool     // the last parameter collects the remaining args
+	ReturnType string
 	Body       *BlockNode
+	// the body contains 'yield', calls return a generator
+	IsGenerator bool
 }
 
 func (s *StructMethodDef) String() string {
@@ -223,6 +284,9 @@ func (s *StructMethodDef) String() string {
 	} else {
 		str += "priv "
 	}
+	if s.IsStatic {
+		str += "static "
+	}
 
 	str += s.StructName + "->"
 	str += s.MethodName + " "
@@ -266,14 +330,46 @@ func (s *StructMethodCall) String() string {
 // Variable declaration (e.g., var x = 5)
 type VarDefNode struct {
 	Position
-	Name  string
-	Value Node
+	Name      string
+	Type      string // "" when not annotated
+	Value     Node
+	IsPrivate bool
+	// set instead of Name for 'var (a, b) = ...'
+	Pattern *Destructure
 }
 
 func (n *VarDefNode) String() string {
+	if n.Pattern != nil {
+		return fmt.Sprintf("@%v: %v", n.Pattern, n.Value.String())
+	}
 	return fmt.Sprintf("@%v: %v", n.Name, n.Value.String())
 }
 
+// Destructuring target of 'var (q, r) = ...', 'var [a, ...rest] = ...'
+// or 'var {x, y: py} = ...', also used by for-in loops.
+type Destructure struct {
+	Position
+	Names  []string // variables to define, '_' skips a value
+	Fields []string // field read into each name, only for '{...}'
+	Rest   string   // '...rest' collecting the remaining elements
+}
+
+// ByField reports whether the pattern reads instance fields.
+func (d *Destructure) ByField() bool {
+	return d.Fields != nil
+}
+
+func (d *Destructure) String() string {
+	names := append([]string{}, d.Names...)
+	if d.Rest != "" {
+		names = append(names, "..."+d.Rest)
+	}
+	if d.ByField() {
+		return "{" + strings.Join(names, ", ") + "}"
+	}
+	return "(" + strings.Join(names, ", ") + ")"
+}
+
 // Assignment (e.g., x = 10)
 type AssignmentNode struct {
 	Position
@@ -291,7 +387,14 @@ type FunctionDefNode struct {
 	Position
 	Name       string
 	Parameters []string
+	ParamTypes []string // "" for parameters without annotation
+	Defaults   []Node   // nil for parameters without a default value
+	Variadic   bool     // the last parameter collects the remaining args
+	ReturnType string
 	Body       *BlockNode
+	IsPrivate  bool
+	// the body contains 'yield', calls return a generator
+	IsGenerator bool
 }
 
 func (f *FunctionDefNode) String() string {
@@ -328,10 +431,24 @@ func (f *FunctionCallNode) String() string {
 	return str
 }
 
+// Named call argument (e.g., greet(name: "x"))
+type NamedArgNode struct {
+	Position
+	Name  string
+	Value Node
+}
+
+func (n *NamedArgNode) String() string {
+	return n.Name + ": " + n.Value.String()
+}
+
 // Block of statements (e.g., { ... })
 type BlockNode struct {
 	Position
 	Statements []Node
+	// set by internal/resolver when nothing defined in the block, such as
+	// a function, keeps its env alive after the block ends
+	Reusable bool
 }
 
 func (b *BlockNode) String() string {
@@ -372,6 +489,7 @@ type WhileNode struct {
 	Position
 	Condition Node
 	Body      *BlockNode
+	Label     string
 }
 
 func (w *WhileNode) String() string {
@@ -385,6 +503,7 @@ type ForNode struct {
 	Condition Node
 	Post      Node // e.g., AssignmentNode
 	Body      *BlockNode
+	Label     string
 }
 
 func (f *ForNode) String() string {
@@ -395,14 +514,76 @@ func (f *ForNode) String() string {
 		f.Body)
 }
 
+// for (name in iterable) { ... }, also spelled 'foreach'
+type ForInNode struct {
+	Position
+	Var      string
+	Pattern  *Destructure // set instead of Var for 'for ((k, v) in ...)'
+	Iterable Node
+	Body     *BlockNode
+	Label    string
+}
+
+func (f *ForInNode) String() string {
+	target := f.Var
+	if f.Pattern != nil {
+		target = f.Pattern.String()
+	}
+	return fmt.Sprintf("for (%s in %v) %v",
+		target, f.Iterable.String(), f.Body.String())
+}
+
+// do { ... } while (cond);
+type DoWhileNode struct {
+	Position
+	Body      *BlockNode
+	Condition Node
+	Label     string
+}
+
+func (d *DoWhileNode) String() string {
+	return fmt.Sprintf("do %v while (%v)",
+		d.Body.String(), d.Condition.String())
+}
+
+// Start..End, the ints from Start up to End, not included
+type RangeNode struct {
+	Position
+	Start Node
+	End   Node
+}
+
+func (r *RangeNode) String() string {
+	return r.Start.String() + ".." + r.End.String()
+}
+
+// break, or 'break label' to leave an enclosing labeled loop
 type BreakNode struct {
 	Position
+	Label string
 }
 
 func (b *BreakNode) String() string {
+	if b.Label != "" {
+		return "break " + b.Label
+	}
 	return "break"
 }
 
+// continue, or 'continue label' to skip to the next iteration of an
+// enclosing labeled loop
+type ContinueNode struct {
+	Position
+	Label string
+}
+
+func (c *ContinueNode) String() string {
+	if c.Label != "" {
+		return "continue " + c.Label
+	}
+	return "continue"
+}
+
 // Return statement
 type ReturnNode struct {
 	Position
@@ -414,6 +595,30 @@ func (r *ReturnNode) String() string {
 	return r.Value.String()
 }
 
+// 'yield value;' hands a value to whoever iterates the generator
+type YieldNode struct {
+	Position
+	Value Node // nil for 'yield;'
+}
+
+func (y *YieldNode) String() string {
+	if y.Value == nil {
+		return "yield"
+	}
+	return "yield " + y.Value.String()
+}
+
+// SpawnNode runs Call, a function or method call, on its own goroutine
+// and evaluates to a task.
+type SpawnNode struct {
+	Position
+	Call Node
+}
+
+func (s *SpawnNode) String() string {
+	return "spawn " + s.Call.String()
+}
+
 // Expression statement (e.g., a function call as a statement)
 type ExpressionStatementNode struct {
 	Position
@@ -442,3 +647,96 @@ type NilNode struct {
 func (n *NilNode) String() string {
 	return "nil"
 }
+
+// match (subject) { patterns => body, ... }
+type MatchNode struct {
+	Position
+	Subject Node
+	Arms    []*MatchArm
+}
+
+func (m *MatchNode) String() string {
+	var str strings.Builder
+	str.WriteString("match (" + m.Subject.String() + ") {\n")
+	for _, arm := range m.Arms {
+		str.WriteString(arm.String() + "\n")
+	}
+	str.WriteString("}")
+	return str.String()
+}
+
+// MatchArm runs Body when any of its Patterns matches.
+type MatchArm struct {
+	Position
+	Patterns []Node
+	Body     Node
+}
+
+func (a *MatchArm) String() string {
+	patterns := make([]string, len(a.Patterns))
+	for i, pattern := range a.Patterns {
+		patterns[i] = pattern.String()
+	}
+	return strings.Join(patterns, ", ") + " => " + a.Body.String()
+}
+
+// '_' matches everything
+type WildcardPattern struct {
+	Position
+}
+
+func (w *WildcardPattern) String() string {
+	return "_"
+}
+
+// ValuePattern matches values equal to Value, e.g. '1', "x" or 'Color.Red'
+type ValuePattern struct {
+	Position
+	Value Node
+}
+
+func (v *ValuePattern) String() string {
+	return v.Value.String()
+}
+
+// RangePattern matches numbers in [Low, High)
+type RangePattern struct {
+	Position
+	Low  Node
+	High Node
+}
+
+func (r *RangePattern) String() string {
+	return r.Low.String() + ".." + r.High.String()
+}
+
+// TypePattern matches values of a builtin type or class, e.g. 'int'
+type TypePattern struct {
+	Position
+	TypeName string
+}
+
+func (t *TypePattern) String() string {
+	return t.TypeName
+}
+
+// StructPattern matches instances of TypeName whose fields match, e.g.
+// 'Point{x: 0}'
+type StructPattern struct {
+	Position
+	TypeName string
+	Fields   []*FieldPattern
+}
+
+func (s *StructPattern) String() string {
+	fields := make([]string, len(s.Fields))
+	for i, field := range s.Fields {
+		fields[i] = field.Name + ": " + field.Pattern.String()
+	}
+	return s.TypeName + "{" + strings.Join(fields, ", ") + "}"
+}
+
+type FieldPattern struct {
+	Name    string
+	Pattern Node
+}
@@ -11,6 +11,8 @@ type Parser struct {
 	MainNode ProgramNode
 	Errors []error
 	pos int
+	// set by 'yield' in the function being parsed, nil outside functions
+	yields *bool
 }
 
 func NewParser(toks []*token.Token) *Parser {
@@ -68,11 +70,34 @@ func (p *Parser) parseStatement() Node {
 		node := p.parseStructDef()
 		return node
 	}
+	case token.Match: {
+		node := p.parseMatch()
+		return node
+	}
+	case token.Enum: {
+		node := p.parseEnumDef()
+		return node
+	}
 	case token.Public, token.Private: {
+		node := p.parseVisibility()
+		return node
+	}
+	case token.Static: {
 		node := p.parseStructMethodDef()
 		return node
 	}
+	case token.Break, token.Continue: {
+		node := p.parseLoopJump()
+		return node
+	}
+	case token.Yield: {
+		node := p.parseYield()
+		return node
+	}
 	case token.Identifier: {
+		if next := p.nextToken(); next != nil && next.TType == token.Colon {
+			return p.parseLabeledLoop()
+		}
 		node := p.parseIdentifier()
 		return node
 	}
@@ -84,10 +109,14 @@ func (p *Parser) parseStatement() Node {
 		node := p.parseWhile()
 		return node
 	}
-	case token.For: {
+	case token.For, token.Foreach: {
 		node := p.parseForLoop()
 		return node
 	}
+	case token.Do: {
+		node := p.parseDoWhile()
+		return node
+	}
 	case token.Semicolon:
 		p.advance()
 		return &SemicolonNode{}
@@ -99,7 +128,7 @@ func (p *Parser) parseStatement() Node {
 		// skip 'return'
 		initTok := p.currentToken()
 		p.advance()
-		if p.currentToken().TType == token.Semicolon {
+		if p.expect(token.Semicolon) {
 			p.advance()
 			return &ReturnNode {
 				Position {
@@ -110,13 +139,39 @@ func (p *Parser) parseStatement() Node {
 			}
 		}
 		value := p.parseExpression(0)
+		if value == nil {
+			return nil
+		}
+		// 'return a, b;' returns both values in an array
+		if p.currentToken() != nil && p.currentToken().TType == token.Comma {
+			values := &ArrayNode{
+				Position: Position{
+					Row: initTok.Line,
+					Column: initTok.Column,
+				},
+				Elements: []Node{value},
+			}
+			for p.currentToken() != nil && p.currentToken().TType == token.Comma {
+				p.advance()
+				next := p.parseExpression(0)
+				if next == nil {
+					return nil
+				}
+				values.Elements = append(values.Elements, next)
+			}
+			value = values
+		}
 		// expecting to advace ';'
-		if p.currentToken().TType != token.Semicolon {
+		if p.currentToken() == nil || p.currentToken().TType != token.Semicolon {
 			p.genError("Expected ';' after value in return block")
 			return nil
 		}
 		p.advance()
 		return &ReturnNode{
+			Position: Position{
+				Row: initTok.Line,
+				Column: initTok.Column,
+			},
 			Value: value,
 		}
 
@@ -124,12 +179,15 @@ func (p *Parser) parseStatement() Node {
         // Try to parse as an expression statement
         expr := p.parseExpression(0)
         if expr == nil {
-            p.genError(fmt.Sprintf("Unknown token: %v", p.currentToken().Lexeme))
+            // at the end of the input the expression reported it
+            if tok := p.currentToken(); tok != nil {
+                p.genError(fmt.Sprintf("Unknown token: %v", tok.Lexeme))
+            }
 			return nil
         }
         // Expect semicolon after expression statement
         if p.currentToken() == nil || p.currentToken().TType != token.Semicolon {
-            p.genError(fmt.Sprintf("expected ';' after expression statement", p.currentToken().Lexeme))
+            p.genError("expected ';' after expression statement")
 			return nil
         }
         p.advance()
@@ -138,19 +196,31 @@ func (p *Parser) parseStatement() Node {
 }
 
 func (p *Parser) parseImport() *ImportNode {
+	initTok := p.currentToken()
+	pos := Position{
+		Row: initTok.Line,
+		Column: initTok.Column,
+	}
 	p.advance()
+	if p.endOfInput("module name after 'import'") {
+		return nil
+	}
 	file := p.currentToken().Lexeme
 	p.advance()
-	if p.currentToken().TType == token.Semicolon {
+	if p.expect(token.Semicolon) {
 		p.advance()
 		return &ImportNode{
+			Position: pos,
 			File: file,
 		}
-	} else if p.currentToken().TType == token.More {
+	} else if p.expect(token.More) {
 		p.advance()
 		var symbols []string
 
-		for p.currentToken().TType != token.Semicolon {
+		for !p.expect(token.Semicolon) {
+			if p.endOfInput("';' after imported names") {
+				return nil
+			}
 			if p.currentToken().TType == token.Comma {
 				p.advance()
 				continue
@@ -162,10 +232,13 @@ func (p *Parser) parseImport() *ImportNode {
 		}
 
 		return &ImportNode{
+			Position: pos,
 			File: file,
 			Symbols: symbols,
 		}
-	} 
+	} else if p.endOfInput("';' after import") {
+		return nil
+	}
 	p.genError("Unknown")
 	return nil
 }
@@ -174,21 +247,26 @@ func (p *Parser) parseIf() *IfNode {
 	initTok := p.currentToken()
 	p.advance()
 
-    if p.currentToken().TType != token.LParen {
+    if !p.expect(token.LParen) {
         p.genError("Expected '(' after 'if'")
         return nil
     }
     p.advance() // consume '('
 
 	condition := p.parseExpression(0)
+	if condition == nil {
+		return nil
+	}
 
-    if p.currentToken().TType != token.RParen {
+    if !p.expect(token.RParen) {
         p.genError("Expected ')' after if condition")
         return nil
     }
     p.advance() // consume ')'
 
-    p.advance() // consume '{'
+    if !p.expectAndAdvance(token.LCurly) {
+        return nil
+    }
 	thenBranch := p.parseBlock()
 
 	var elseBranch *BlockNode = nil
@@ -198,15 +276,21 @@ func (p *Parser) parseIf() *IfNode {
         if p.currentToken() != nil && p.currentToken().TType == token.If {
             // else if: recursively parse as a nested IfNode in the else branch
             elseIfNode := p.parseIf()
+            if elseIfNode == nil {
+                return nil
+            }
             // Wrap the else-if IfNode in a block (so AST is consistent)
+            end := p.currentOrLastToken()
             elseBranch = &BlockNode{
 				Position: Position {
-					Row: p.currentToken().Line,
-					Column: p.currentToken().Column,
+					Row: end.Line,
+					Column: end.Column,
 				},
 				Statements: []Node{elseIfNode}}
         } else {
-			p.advance()
+            if !p.expectAndAdvance(token.LCurly) {
+                return nil
+            }
             elseBranch = p.parseBlock()
         }
     }
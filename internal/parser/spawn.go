@@ -0,0 +1,32 @@
+package parser
+
+// parseSpawn parses 'spawn f(args)' and 'spawn obj.method(args)'.
+func (p *Parser) parseSpawn() *SpawnNode {
+	tok := p.currentToken()
+	p.advance() // skip 'spawn'
+	if p.endOfInput("call after 'spawn'") {
+		return nil
+	}
+	call := p.parseExpression(0)
+	if call == nil {
+		return nil
+	}
+	switch c := call.(type) {
+	case *FunctionCallNode:
+	case *StructMethodCall:
+		if c.IsField {
+			p.genError("'spawn' expects a function or method call")
+			return nil
+		}
+	default:
+		p.genError("'spawn' expects a function or method call")
+		return nil
+	}
+	return &SpawnNode{
+		Position: Position{
+			Row:    tok.Line,
+			Column: tok.Column,
+		},
+		Call: call,
+	}
+}
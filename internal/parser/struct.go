@@ -1,6 +1,9 @@
 package parser
 
-import "lang/internal/token"
+import (
+	"fmt"
+	"lang/internal/token"
+)
 
 
 func (p *Parser) parseStructDef() *StructDefNode {
@@ -13,7 +16,7 @@ func (p *Parser) parseStructDef() *StructDefNode {
 	name := nameTok.Lexeme
 	p.advance() // skip struct name
 
-	if p.currentToken().TType != token.LCurly {
+	if !p.expect(token.LCurly) {
 		p.genError("Expected '{' after struct name")
 		return nil
 	}
@@ -27,6 +30,9 @@ func (p *Parser) parseStructDef() *StructDefNode {
 			continue
 		}
 		field := p.parseStructField()
+		if field == nil {
+			return nil
+		}
 		fields = append(fields, field)
 	}
 
@@ -68,7 +74,11 @@ func (p *Parser) parseStructMethodCall(node Node) *StructMethodCall {
 					p.advance()
 					continue
 				}
-				args = append(args, p.parseValue())
+				arg := p.parseArg(p.parseValue)
+				if arg == nil {
+					return nil
+				}
+				args = append(args, arg)
 			}
 			if p.currentToken() != nil && p.currentToken().TType == token.RParen {
 				p.advance() // skip ')'
@@ -106,20 +116,48 @@ func (p *Parser) parseStructMethodCall(node Node) *StructMethodCall {
 
 func (p *Parser) parseStructField() *StructField {
 	var isPub bool = true
-	if p.currentToken().TType == token.Private {
+	switch p.currentToken().TType {
+	case token.Private:
 		isPub = false
+		p.advance()
+	case token.Public:
+		p.advance()
+	}
+	isStatic := false
+	if p.expect(token.Static) {
+		isStatic = true
+		p.advance()
 	}
-	p.advance()
 	nameTok := p.currentToken()
+	if p.endOfInput("field name") {
+		return nil
+	}
+	if nameTok.TType != token.Identifier {
+		p.genError(fmt.Sprintf("Expected field name; But got: %v", nameTok.Lexeme))
+		return nil
+	}
 	name := nameTok.Lexeme
 	p.advance()
-	if p.currentToken().TType == token.Assign {
+	fieldType, ok := p.parseOptionalType()
+	if !ok {
+		return nil
+	}
+	if p.expect(token.Assign) {
 		p.advance()
 		value := p.parseValue()
+		if value == nil {
+			return nil
+		}
 		return &StructField{
+			Position: Position {
+				Row: nameTok.Line,
+				Column: nameTok.Column,
+			},
 			Name: name,
+			Type: fieldType,
 			Value: value,
 			IsPublic: isPub,
+			IsStatic: isStatic,
 		}
 	} 
 
@@ -129,17 +167,78 @@ func (p *Parser) parseStructField() *StructField {
 			Column: nameTok.Column,
 		},
 		Name: name,
+		Type: fieldType,
 		Value: nil,
 		IsPublic: isPub,
+		IsStatic: isStatic,
+	}
+}
+
+// 'pub'/'pri' in front of a top level declaration or a class method.
+func (p *Parser) parseVisibility() Node {
+	isPrivate := p.currentToken().TType == token.Private
+	next := p.nextToken()
+	if next == nil {
+		p.genError("Expected declaration after visibility modifier")
+		return nil
+	}
+
+	switch next.TType {
+	case token.Func:
+		p.advance()
+		node := p.parseFuncDef()
+		if node == nil {
+			return nil
+		}
+		node.IsPrivate = isPrivate
+		return node
+	case token.Var:
+		p.advance()
+		node := p.parseVarDef()
+		if node == nil {
+			return nil
+		}
+		node.IsPrivate = isPrivate
+		return node
+	case token.Struct:
+		p.advance()
+		node := p.parseStructDef()
+		if node == nil {
+			return nil
+		}
+		node.IsPrivate = isPrivate
+		return node
+	case token.Enum:
+		p.advance()
+		node := p.parseEnumDef()
+		if node == nil {
+			return nil
+		}
+		node.IsPrivate = isPrivate
+		return node
+	}
+
+	node := p.parseStructMethodDef()
+	if node == nil {
+		return nil
 	}
+	return node
 }
 
 func (p *Parser) parseStructMethodDef() *StructMethodDef {
 	isPub := true
-	if p.currentToken() != nil && p.currentToken().TType == token.Private {
+	switch p.currentToken().TType {
+	case token.Private:
 		isPub = false
+		p.advance()
+	case token.Public:
+		p.advance()
+	}
+	isStatic := false
+	if p.currentToken() != nil && p.currentToken().TType == token.Static {
+		isStatic = true
+		p.advance()
 	}
-	p.advance()
 	if p.currentToken() == nil {
 		p.genError("Expected struct name")
 		return nil
@@ -163,41 +262,42 @@ func (p *Parser) parseStructMethodDef() *StructMethodDef {
 		return nil
 	}
 	p.advance()
-	var params []string
-	for p.currentToken() != nil && p.currentToken().TType != token.RParen {
-		if p.currentToken().TType == token.Comma {
-			p.advance()
-			continue
-		}
-		params = append(params, p.currentToken().Lexeme)
-		p.advance()
+	params, ok := p.parseParams()
+	if !ok {
+		return nil
 	}
-	if p.currentToken() == nil || p.currentToken().TType != token.RParen {
-		p.genError("Expected ')' after parameter list")
+	returnType, ok := p.parseOptionalType()
+	if !ok {
 		return nil
 	}
-	p.advance() // skip ')'
 	if p.currentToken() == nil || p.currentToken().TType != token.LCurly {
 		p.genError("Expected '{' to start method body")
 		return nil
 	}
 	p.advance()
-	body := p.parseBlock()
+	body, isGenerator := p.parseFuncBody()
 	return &StructMethodDef{
 		Position: Position {
 			Row: nameTok.Line,
 			Column: nameTok.Column,
 		},
 		IsPub: isPub,
+		IsStatic: isStatic,
 		StructName: structName,
 		MethodName: methodName,
-		Parameters: params,
+		Parameters: params.Names,
+		ParamTypes: params.Types,
+		Defaults: params.Defaults,
+		Variadic: params.Variadic,
+		ReturnType: returnType,
 		Body: body,
+		IsGenerator: isGenerator,
 	}
 }
 
 func (p *Parser) parseStructInit() *StructInitNode {
-	structName := p.currentToken().Lexeme
+	nameTok := p.currentToken()
+	structName := nameTok.Lexeme
 	p.advance() // skip name
 	p.advance() // skip '{'
 
@@ -210,25 +310,61 @@ func (p *Parser) parseStructInit() *StructInitNode {
 		}
 		fieldName := p.currentToken().Lexeme
 		p.advance()
-		if p.currentToken().TType != token.Colon {
+		if !p.expect(token.Colon) {
 			p.genError("Expected ':' and field name")
 			return nil
 		}
 		p.advance() // skip '='
 		value := p.parseValue()
+		if value == nil {
+			return nil
+		}
 		fieldAssign := &AssignmentNode{
 			Name: &IdentifierNode{Name: fieldName},
 			Value: value,
 		}
 		fieldsInit = append(fieldsInit, fieldAssign)
 	}
-	if p.currentToken().TType != token.RCurly {
+	if !p.expect(token.RCurly) {
 		p.genError("Expected '}' after struct init")
 		return nil
 	}
 	p.advance()
 	return &StructInitNode{
+		Position: Position {
+			Row: nameTok.Line,
+			Column: nameTok.Column,
+		},
 		Name: structName,
 		InitFields: fieldsInit,
 	}
 }
+
+// new Name(args)
+func (p *Parser) parseNew() *StructInitNode {
+	newTok := p.currentToken()
+	p.advance() // skip 'new'
+	nameTok := p.currentToken()
+	if nameTok == nil || nameTok.TType != token.Identifier {
+		p.genError("Expected class name after 'new'")
+		return nil
+	}
+	p.advance()
+	if p.currentToken() == nil || p.currentToken().TType != token.LParen {
+		p.genError("Expected '(' after class name")
+		return nil
+	}
+	call := p.parseFuncCall(&IdentifierNode{Name: nameTok.Lexeme})
+	if call == nil {
+		return nil
+	}
+	return &StructInitNode{
+		Position: Position {
+			Row: newTok.Line,
+			Column: newTok.Column,
+		},
+		Name: nameTok.Lexeme,
+		Args: call.Args,
+		IsCall: true,
+	}
+}
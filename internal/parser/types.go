@@ -0,0 +1,110 @@
+package parser
+
+import (
+	"fmt"
+	"lang/internal/token"
+)
+
+// parseTypeAnnotation parses the type after ':', e.g. 'int', 'Point',
+// '[]' or '[]int'.
+func (p *Parser) parseTypeAnnotation() (string, bool) {
+	prefix := ""
+	if p.currentToken() != nil && p.currentToken().TType == token.LBrace {
+		p.advance() // skip '['
+		if !p.expectAndAdvance(token.RBrace) {
+			return "", false
+		}
+		prefix = "[]"
+		if p.currentToken() == nil || p.currentToken().TType != token.Identifier {
+			return prefix, true
+		}
+	}
+
+	tok := p.currentToken()
+	if tok == nil || (tok.TType != token.Identifier && tok.TType != token.Nil) {
+		p.genError("Expected type name")
+		return "", false
+	}
+	p.advance()
+	return prefix + tok.Lexeme, true
+}
+
+// parseOptionalType parses ': type' if present, "" means no annotation.
+func (p *Parser) parseOptionalType() (string, bool) {
+	if p.currentToken() == nil || p.currentToken().TType != token.Colon {
+		return "", true
+	}
+	p.advance() // skip ':'
+	return p.parseTypeAnnotation()
+}
+
+// ParamList is the parsed parameter list of a function or method.
+type ParamList struct {
+	Names []string
+	// "" for parameters without an annotation
+	Types []string
+	// nil for parameters without a default value
+	Defaults []Node
+	// the last parameter is '...name' and collects the remaining args
+	Variadic bool
+}
+
+// parseParams parses 'a, b: int, c = 1, ...rest)' after the opening '('
+// of a function or method.
+func (p *Parser) parseParams() (params ParamList, ok bool) {
+	for p.currentToken() != nil && p.currentToken().TType != token.RParen {
+		if p.currentToken().TType == token.Comma {
+			p.advance()
+			continue
+		}
+		if params.Variadic {
+			p.genError(fmt.Sprintf(
+				"Variadic parameter '%s' must be the last one",
+				params.Names[len(params.Names)-1]))
+			return params, false
+		}
+		if p.expect(token.Ellipsis) {
+			params.Variadic = true
+			p.advance()
+		}
+		if p.currentToken() == nil || p.currentToken().TType != token.Identifier {
+			p.genError("Expected parameter name")
+			return params, false
+		}
+		name := p.currentToken().Lexeme
+		params.Names = append(params.Names, name)
+		p.advance()
+
+		paramType, ok := p.parseOptionalType()
+		if !ok {
+			return params, false
+		}
+		params.Types = append(params.Types, paramType)
+
+		var value Node
+		if p.currentToken() != nil && p.currentToken().TType == token.Assign {
+			if params.Variadic {
+				p.genError(fmt.Sprintf(
+					"Variadic parameter '%s' can't have a default value", name))
+				return params, false
+			}
+			p.advance() // skip '='
+			if value = p.parseExpression(0); value == nil {
+				return params, false
+			}
+		} else if !params.Variadic && len(params.Defaults) > 0 &&
+			params.Defaults[len(params.Defaults)-1] != nil {
+			p.genError(fmt.Sprintf(
+				"Parameter '%s' without a default value follows one with a default",
+				name))
+			return params, false
+		}
+		params.Defaults = append(params.Defaults, value)
+	}
+	if p.currentToken() == nil {
+		p.genError("Expected ')' after parameter list")
+		return params, false
+	}
+	p.advance() // skip ')'
+	return params, true
+}
@@ -7,13 +7,35 @@ import (
 )
 
 func (p *Parser) genError(message string) {
-	msg := fmt.Sprintf("Parse error in %d:%d: %s",
-		p.currentToken().Line,
-		p.currentToken().Column,
-		message)
+	// at the end of the input errors point at the last token
+	tok := p.currentOrLastToken()
+	line, column := 0, 0
+	if tok != nil {
+		line, column = tok.Line, tok.Column
+	}
+	msg := fmt.Sprintf("Parse error in %d:%d: %s", line, column, message)
 	p.Errors = append(p.Errors, errors.New(msg))
 }
 
+// currentOrLastToken is the current token, or the last one at the end
+// of the input.
+func (p *Parser) currentOrLastToken() *token.Token {
+	if tok := p.currentToken(); tok != nil || p.TokensLength == 0 {
+		return tok
+	}
+	return p.Tokens[p.TokensLength-1]
+}
+
+// endOfInput reports what was expected when the input ends early, true
+// if it did.
+func (p *Parser) endOfInput(expected string) bool {
+	if p.currentToken() != nil {
+		return false
+	}
+	p.genError(fmt.Sprintf("Expected %s; But got: end of input", expected))
+	return true
+}
+
 func tokenTypeToString(t token.TokenType) string {
     switch t {
     case token.Plus:      return "+"
@@ -34,9 +56,12 @@ func tokenTypeToString(t token.TokenType) string {
 }
 
 func (p *Parser) expectAndAdvance(tType token.TokenType) bool {
-	if p.currentToken().TType == tType {
+	if p.expect(tType) {
 		p.advance()
 		return true
+	} else if p.currentToken() == nil {
+		p.genError(fmt.Sprintf("Expected %v; But got: end of input", tType))
+		return false
 	} else {
 		p.genError(fmt.Sprintf("Expected %v; But got: %v",
 			tType, p.currentToken().Lexeme))
@@ -45,7 +70,7 @@ func (p *Parser) expectAndAdvance(tType token.TokenType) bool {
 }
 
 func (p *Parser) expect(tType token.TokenType) bool {
-	if p.currentToken().TType == tType {
+	if tok := p.currentToken(); tok != nil && tok.TType == tType {
 		return true
 	}
 	return false
@@ -39,7 +39,14 @@ func (p *Parser) parseDigit() *LiteralNode {
 }
 
 func (p *Parser) parseString() *LiteralNode {
-	str := LiteralNode{Value: p.currentToken().Lexeme}
+	tok := p.currentToken()
+	str := LiteralNode{
+		Position: Position{
+			Row:    tok.Line,
+			Column: tok.Column,
+		},
+		Value: tok.Lexeme,
+	}
 	p.advance()
 	return &str
 }
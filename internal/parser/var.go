@@ -7,6 +7,9 @@ import (
 
 func (p *Parser) parseVarDef() *VarDefNode {
 	p.advance()
+	if isDestructureStart(p.currentToken()) {
+		return p.parseDestructuringDef()
+	}
 	nameTok := p.currentToken()
 	if nameTok == nil || nameTok.TType != token.Identifier {
 		p.genError(fmt.Sprintf(
@@ -16,11 +19,21 @@ func (p *Parser) parseVarDef() *VarDefNode {
 	name := nameTok.Lexeme
 	p.advance()
 
+	varType, ok := p.parseOptionalType()
+	if !ok {
+		return nil
+	}
+
 	assignTok := p.currentToken()
 	if assignTok == nil || assignTok.TType != token.Assign {
 		p.advance()
 		return &VarDefNode{
+			Position: Position {
+				Row: nameTok.Line,
+				Column: nameTok.Column,
+			},
 			Name: name,
+			Type: varType,
 			Value: nil,
 		}
 	}
@@ -36,7 +49,27 @@ func (p *Parser) parseVarDef() *VarDefNode {
 			Row: nameTok.Line,
 			Column: nameTok.Column,
 		},
-		Name: name, Value: value}
+		Name: name, Type: varType, Value: value}
+}
+
+// parseDestructuringDef parses the rest of 'var (q, r) = value;'.
+func (p *Parser) parseDestructuringDef() *VarDefNode {
+	pattern := p.parseDestructure()
+	if pattern == nil {
+		return nil
+	}
+	if !p.expectAndAdvance(token.Assign) {
+		return nil
+	}
+	value := p.parseValue()
+	if value == nil || !p.expectAndAdvance(token.Semicolon) {
+		return nil
+	}
+	return &VarDefNode{
+		Position: pattern.Position,
+		Pattern: pattern,
+		Value: value,
+	}
 }
 
 func (p *Parser) parseIdentifier() Node {
@@ -60,20 +93,23 @@ func (p *Parser) parseIdentifier() Node {
     // Handle any number of array accesses: x[i][j][k]
 	for {
 		if p.currentToken() != nil && p.currentToken().TType == token.LBrace {
-			node = p.parseArrayAccess(node)
-			if node == nil {
+			access := p.parseArrayAccess(node)
+			if access == nil {
 				return nil
 			}
+			node = access
 		} else if p.currentToken() != nil && p.currentToken().TType == token.Dot {
-			node = p.parseStructMethodCall(node)
-			if node == nil {
+			call := p.parseStructMethodCall(node)
+			if call == nil {
 				return nil
 			}
+			node = call
 		} else if p.currentToken() != nil && p.currentToken().TType == token.LParen {
-			node = p.parseFuncCall(node)
-			if node == nil {
+			call := p.parseFuncCall(node)
+			if call == nil {
 				return nil
 			}
+			node = call
 		} else {
 			break
 		}
@@ -85,6 +121,9 @@ func (p *Parser) parseIdentifier() Node {
 		op := p.currentToken().Lexeme
 		p.advance()
         value := p.parseValue()
+        if value == nil {
+            return nil
+        }
         return &AssignmentNode{
 			Position: Position {
 				Row: id.Line,
@@ -100,6 +139,9 @@ func (p *Parser) parseIdentifier() Node {
 }
 
 func (p *Parser) parseValue() Node {
+	if p.endOfInput("value") {
+		return nil
+	}
 	currTok := p.currentToken()
 	if currTok.TType == token.Nil {
 		p.advance()
@@ -114,7 +156,7 @@ func (p *Parser) parseValue() Node {
 		return p.parseArray()
 	}
 	if currTok.TType == token.Identifier &&
-		p.nextToken().TType == token.LCurly {
+		p.nextToken() != nil && p.nextToken().TType == token.LCurly {
 		return p.parseStructInit()
 	}
 	if currTok.TType == token.True {
@@ -3,6 +3,7 @@ package repl
 import (
 	"bufio"
 	"fmt"
+	"lang/internal/core"
 	"lang/internal/env"
 	"lang/internal/eval"
 	"lang/internal/lexer"
@@ -65,8 +66,31 @@ func (r *Repl) eval(source string) error {
 			}
 		} else {
 			r.Environment = evaluator.Environment
+			r.printResult(evaluator, mnode)
 		}
 	}
 
 	return nil
 }
+
+// printResult echoes the value of a trailing expression, so typing
+// 'p' or '[1, 2]' shows the value without calling print.
+func (r *Repl) printResult(evaluator *eval.Evaluator, mnode *parser.ProgramNode) {
+	value := evaluator.Last
+	if len(mnode.Nodes) == 0 || value == nil {
+		return
+	}
+	switch mnode.Nodes[len(mnode.Nodes)-1].(type) {
+	case *parser.BinaryOpNode, *parser.UnaryOpNode, *parser.IdentifierNode,
+		*parser.LiteralNode, *parser.TrueNode, *parser.FalseNode,
+		*parser.ArrayNode, *parser.ArrayAccessNode, *parser.FunctionCallNode,
+		*parser.StructInitNode, *parser.StructMethodCall,
+		*parser.ExpressionStatementNode:
+	default:
+		return
+	}
+	if _, ok := value.(core.NilValue); ok {
+		return
+	}
+	fmt.Println(evaluator.FormatValue(value))
+}
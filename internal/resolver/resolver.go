@@ -0,0 +1,464 @@
+// Package resolver walks a program before it runs. It reports the
+// scope errors it can find without running it, and works out where the
+// local variables of functions and methods will live, so the evaluator
+// can find them by slot instead of searching every env by name.
+package resolver
+
+import (
+	"fmt"
+	"lang/internal/parser"
+	"slices"
+	"unicode"
+)
+
+// scope mirrors an env the evaluator creates: the global env, the call
+// env holding the parameters, a block or a for-in loop.
+type scope struct {
+	// names in the order the evaluator defines them, which is their slot
+	names []string
+	// the global env, its names are always looked up by name
+	global bool
+	// the call env, slots aren't resolved past it
+	frame bool
+	// an import defined names at runtime, so the slots after it are unknown
+	dynamic bool
+	// names can't all be known before running, e.g. the public classes
+	// of an imported module or the members of a builtin class
+	open bool
+}
+
+func (s *scope) slot(name string) int {
+	return slices.Index(s.names, name)
+}
+
+// class holds what the methods of a class can see by name.
+type class struct {
+	names []string
+	// scopes enclosing the class definition
+	chain []*scope
+}
+
+type resolver struct {
+	scopes []*scope
+	// blocks enclosing the current statement, up to the function body
+	blocks []*parser.BlockNode
+	// labels of the loops enclosing the current statement, "" for
+	// unlabeled ones, up to the function body
+	loops []string
+	// inside a function or method body
+	inFunction bool
+	// function and method bodies, resolved once the scopes around them
+	// are complete
+	deferred []func()
+	classes  map[string]*class
+	// methods of each class, wherever they are defined
+	methods map[string][]string
+	known   func(name string) bool
+	errors  []error
+}
+
+// Resolve annotates the identifiers of program that refer to locals of
+// the enclosing function with their env depth and slot, and marks the
+// blocks whose env has to outlive them. The evaluator checks every
+// resolved slot before using it, so resolving is only ever a hint.
+//
+// It returns the uses of undeclared names, duplicate declarations in a
+// scope and misplaced return, self, break and continue. known reports
+// the names defined before the program runs, like builtins.
+func Resolve(program *parser.ProgramNode, known func(name string) bool) []error {
+	r := &resolver{
+		classes: make(map[string]*class),
+		methods: make(map[string][]string),
+		known:   known,
+	}
+	for _, node := range program.Nodes {
+		if def, ok := node.(*parser.StructMethodDef); ok {
+			r.methods[def.StructName] = append(r.methods[def.StructName], def.MethodName)
+		}
+	}
+	r.scopes = []*scope{{global: true}}
+	for _, node := range program.Nodes {
+		r.stmt(node)
+	}
+	// functions can use names declared after them, as long as they are
+	// called later, so their bodies are resolved last
+	for len(r.deferred) > 0 {
+		fn := r.deferred[0]
+		r.deferred = r.deferred[1:]
+		fn()
+	}
+	return r.errors
+}
+
+func (r *resolver) errorf(pos parser.Position, format string, args ...any) {
+	r.errors = append(r.errors, fmt.Errorf("%d, %d: %s",
+		pos.Row, pos.Column, fmt.Sprintf(format, args...)))
+}
+
+func (r *resolver) push(frame bool) {
+	r.scopes = append(r.scopes, &scope{frame: frame})
+}
+
+func (r *resolver) pop() {
+	r.scopes = r.scopes[:len(r.scopes)-1]
+}
+
+func (r *resolver) current() *scope {
+	return r.scopes[len(r.scopes)-1]
+}
+
+// define adds name to the current scope and reports whether it is new.
+// Redefining a name keeps its slot, as the env does.
+func (r *resolver) define(name string) bool {
+	if name == "" || name == "_" {
+		return true
+	}
+	s := r.current()
+	if s.slot(name) >= 0 {
+		return false
+	}
+	s.names = append(s.names, name)
+	return true
+}
+
+// declare defines name, which the evaluator refuses to redefine.
+func (r *resolver) declare(name string, pos parser.Position) {
+	if !r.define(name) {
+		r.errorf(pos, "'%s' is already declared in this scope", name)
+	}
+}
+
+// lookup resolves id when it names a local of the current function and
+// reports whether the name is declared at all.
+func (r *resolver) lookup(id *parser.IdentifierNode) bool {
+	id.Resolved = false
+	slots := true
+	for depth := 0; depth < len(r.scopes); depth++ {
+		s := r.scopes[len(r.scopes)-1-depth]
+		if s.dynamic {
+			slots = false
+		}
+		if slot := s.slot(id.Name); slot >= 0 {
+			if slots && !s.global {
+				id.Resolved, id.Depth, id.Slot = true, depth, slot
+			}
+			return true
+		}
+		if s.open {
+			return true
+		}
+		if s.frame {
+			slots = false
+		}
+	}
+	return r.known(id.Name)
+}
+
+// use looks up an identifier read or assigned by the program.
+func (r *resolver) use(id *parser.IdentifierNode, format string) {
+	if r.lookup(id) {
+		return
+	}
+	if id.Name == "self" {
+		r.errorf(id.Position, "'self' outside of a method")
+		return
+	}
+	r.errorf(id.Position, format, id.Name)
+}
+
+// capture marks the enclosing blocks as not reusable, their envs are
+// now reachable from something that outlives them.
+func (r *resolver) capture() {
+	for _, block := range r.blocks {
+		block.Reusable = false
+	}
+}
+
+// block resolves a block run in an env of its own.
+func (r *resolver) block(block *parser.BlockNode) {
+	if block == nil {
+		return
+	}
+	block.Reusable = true
+	r.push(false)
+	r.blocks = append(r.blocks, block)
+	for _, stmt := range block.Statements {
+		r.stmt(stmt)
+	}
+	r.blocks = r.blocks[:len(r.blocks)-1]
+	r.pop()
+}
+
+// loop resolves the body of a loop labeled label.
+func (r *resolver) loop(label string, body *parser.BlockNode) {
+	r.loops = append(r.loops, label)
+	r.block(body)
+	r.loops = r.loops[:len(r.loops)-1]
+}
+
+// function resolves a function or method body run in its call env,
+// which holds self (for methods) and the parameters in order, and is
+// nested in the scopes of chain.
+func (r *resolver) function(
+	chain []*scope,
+	self bool,
+	params []string,
+	defaults []parser.Node,
+	body *parser.BlockNode,
+	pos parser.Position) {
+
+	r.scopes = append(chain, &scope{frame: true})
+	r.blocks, r.loops, r.inFunction = nil, nil, true
+	if self {
+		r.define("self")
+	}
+	for i, param := range params {
+		// defaults are evaluated once the parameters before them are bound
+		if i < len(defaults) && defaults[i] != nil {
+			r.expr(defaults[i])
+		}
+		if !r.define(param) {
+			r.errorf(pos, "Duplicate parameter '%s'", param)
+		}
+	}
+	if body != nil {
+		body.Reusable = true
+		r.blocks = append(r.blocks, body)
+		for _, stmt := range body.Statements {
+			r.stmt(stmt)
+		}
+	}
+}
+
+// method resolves a method body. Its call env is nested in the
+// instance, or the class for static methods, so fields and methods can
+// be used by name.
+func (r *resolver) method(def *parser.StructMethodDef) {
+	// builtin and imported classes have members we don't know about
+	chain := []*scope{{open: true}}
+	if c, ok := r.classes[def.StructName]; ok {
+		chain = append(slices.Clone(c.chain), &scope{names: c.names})
+	}
+	r.function(chain, !def.IsStatic, def.Parameters, def.Defaults, def.Body, def.Position)
+}
+
+func (r *resolver) stmt(node parser.Node) {
+	switch n := node.(type) {
+	case *parser.VarDefNode:
+		r.expr(n.Value)
+		if n.Pattern != nil {
+			r.declarePattern(n.Pattern, n.Position)
+		} else {
+			r.declare(n.Name, n.Position)
+		}
+	case *parser.FunctionDefNode:
+		r.declare(n.Name, n.Position)
+		r.capture()
+		chain := slices.Clone(r.scopes)
+		r.deferred = append(r.deferred, func() {
+			r.function(chain, false, n.Parameters, n.Defaults, n.Body, n.Position)
+		})
+	case *parser.StructMethodDef:
+		r.deferred = append(r.deferred, func() { r.method(n) })
+	case *parser.StructDefNode:
+		c := &class{chain: slices.Clone(r.scopes)}
+		for _, field := range n.Fields {
+			r.expr(field.Value)
+			c.names = append(c.names, field.Name)
+		}
+		c.names = append(c.names, r.methods[n.Name]...)
+		r.classes[n.Name] = c
+		r.declare(n.Name, n.Position)
+		r.capture()
+	case *parser.EnumDefNode:
+		r.declare(n.Name, n.Position)
+		r.capture()
+	case *parser.ImportNode:
+		s := r.current()
+		s.dynamic = true
+		if len(n.Symbols) == 0 {
+			// the module's public classes and enums land here too
+			s.open = true
+			r.declare(capitalize(n.File), n.Position)
+			r.declare(n.File, n.Position)
+		}
+		for _, symbol := range n.Symbols {
+			r.declare(symbol, n.Position)
+		}
+		r.capture()
+	case *parser.BlockNode:
+		r.block(n)
+	case *parser.IfNode:
+		r.expr(n.Condition)
+		r.block(n.ThenBranch)
+		r.block(n.ElseBranch)
+	case *parser.WhileNode:
+		r.expr(n.Condition)
+		r.loop(n.Label, n.Body)
+	case *parser.DoWhileNode:
+		r.loop(n.Label, n.Body)
+		r.expr(n.Condition)
+	case *parser.ForNode:
+		// the loop variable is defined in the enclosing env, and may be
+		// defined again by the next loop
+		if def, ok := n.Init.(*parser.VarDefNode); ok {
+			r.expr(def.Value)
+			r.define(def.Name)
+		} else {
+			r.expr(n.Init)
+		}
+		r.expr(n.Condition)
+		r.loop(n.Label, n.Body)
+		r.expr(n.Post)
+	case *parser.ForInNode:
+		r.expr(n.Iterable)
+		r.push(false)
+		if n.Pattern != nil {
+			r.declarePattern(n.Pattern, n.Position)
+		} else {
+			r.define(n.Var)
+		}
+		r.loop(n.Label, n.Body)
+		r.pop()
+	case *parser.BreakNode:
+		r.loopSignal("break", n.Label, n.Position)
+	case *parser.ContinueNode:
+		r.loopSignal("continue", n.Label, n.Position)
+	case *parser.ReturnNode:
+		if !r.inFunction {
+			r.errorf(n.Position, "'return' outside of a function")
+		}
+		r.expr(n.Value)
+	case *parser.YieldNode:
+		r.expr(n.Value)
+	case *parser.ExpressionStatementNode:
+		r.expr(n.Expr)
+	default:
+		r.expr(node)
+	}
+}
+
+// loopSignal checks that a break or continue has a loop to leave.
+func (r *resolver) loopSignal(keyword, label string, pos parser.Position) {
+	if label == "" {
+		if len(r.loops) == 0 {
+			r.errorf(pos, "'%s' outside of a loop", keyword)
+		}
+		return
+	}
+	if !slices.Contains(r.loops, label) {
+		r.errorf(pos, "'%s %s': no enclosing loop is labeled '%s'",
+			keyword, label, label)
+	}
+}
+
+func (r *resolver) declarePattern(pat *parser.Destructure, pos parser.Position) {
+	for _, name := range pat.Names {
+		r.declare(name, pos)
+	}
+	r.declare(pat.Rest, pos)
+}
+
+func (r *resolver) expr(node parser.Node) {
+	switch n := node.(type) {
+	case *parser.IdentifierNode:
+		r.use(n, "Unknown identifier '%s'")
+	case *parser.AssignmentNode:
+		r.expr(n.Value)
+		if id, ok := n.Name.(*parser.IdentifierNode); ok {
+			r.use(id, "Variable '%s' does not exist")
+		} else {
+			r.expr(n.Name)
+		}
+	case *parser.BinaryOpNode:
+		r.expr(n.Left)
+		r.expr(n.Right)
+	case *parser.LogicalExprNode:
+		r.expr(n.Left)
+		r.expr(n.Right)
+	case *parser.UnaryOpNode:
+		r.expr(n.Expr)
+	case *parser.ArrayNode:
+		r.exprs(n.Elements)
+	case *parser.ArrayAccessNode:
+		r.expr(n.Target)
+		r.expr(n.Index)
+	case *parser.ArrayAssign:
+		r.expr(n.Target)
+		r.expr(n.Value)
+	case *parser.RangeNode:
+		r.expr(n.Start)
+		r.expr(n.End)
+	case *parser.FunctionCallNode:
+		// functions are looked up by name, not by slot
+		if id, ok := n.Name.(*parser.IdentifierNode); ok {
+			r.use(id, "Function '%s' not found")
+			id.Resolved = false
+		}
+		r.exprs(n.Args)
+	case *parser.NamedArgNode:
+		r.expr(n.Value)
+	case *parser.StructMethodCall:
+		r.expr(n.Caller)
+		r.exprs(n.Args)
+	case *parser.StructInitNode:
+		r.use(&parser.IdentifierNode{Position: n.Position, Name: n.Name},
+			"Struct type '%s' not found")
+		r.exprs(n.Args)
+		for _, init := range n.InitFields {
+			// the names are fields, not variables
+			if assign, ok := init.(*parser.AssignmentNode); ok {
+				r.expr(assign.Value)
+			}
+		}
+	case *parser.SpawnNode:
+		r.capture()
+		r.expr(n.Call)
+	case *parser.MatchNode:
+		r.expr(n.Subject)
+		for _, arm := range n.Arms {
+			for _, pattern := range arm.Patterns {
+				r.pattern(pattern)
+			}
+			if body, ok := arm.Body.(*parser.BlockNode); ok {
+				r.block(body)
+			} else {
+				r.stmt(arm.Body)
+			}
+		}
+	case *parser.BlockNode, *parser.IfNode, *parser.VarDefNode,
+		*parser.BreakNode, *parser.ContinueNode, *parser.ReturnNode:
+		r.stmt(node)
+	}
+}
+
+func (r *resolver) exprs(nodes []parser.Node) {
+	for _, node := range nodes {
+		r.expr(node)
+	}
+}
+
+func (r *resolver) pattern(node parser.Node) {
+	switch p := node.(type) {
+	case *parser.ValuePattern:
+		r.expr(p.Value)
+	case *parser.RangePattern:
+		r.expr(p.Low)
+		r.expr(p.High)
+	case *parser.StructPattern:
+		for _, field := range p.Fields {
+			r.pattern(field.Pattern)
+		}
+	}
+}
+
+// capitalize names the class a whole module import defines, 'utils'
+// is imported as class 'Utils'.
+func capitalize(s string) string {
+	if len(s) == 0 {
+		return s
+	}
+	runes := []rune(s)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
@@ -22,6 +22,16 @@ const (
     Import
     Private
     Public
+    New
+    Static
+    Enum
+    Match
+    Break
+    Continue
+    Yield
+    Spawn
+    Do
+    In
 
     True
     False
@@ -65,6 +75,9 @@ const (
 
     Comma
     Dot
+    DotDot   // ..
+    Ellipsis // ...
+    FatArrow // =>
 
     Comment
 
@@ -110,6 +123,26 @@ func (t TokenType) String() string {
         return "Private"
     case Public:
         return "Public"
+    case New:
+        return "New"
+    case Static:
+        return "Static"
+    case Enum:
+        return "Enum"
+    case Match:
+        return "Match"
+    case Break:
+        return "Break"
+    case Continue:
+        return "Continue"
+    case Yield:
+        return "Yield"
+    case Spawn:
+        return "Spawn"
+    case Do:
+        return "Do"
+    case In:
+        return "In"
     case True:
         return "True"
     case False:
@@ -180,6 +213,12 @@ func (t TokenType) String() string {
         return "Comma"
     case Dot:
         return "Dot"
+    case DotDot:
+        return "DotDot"
+    case Ellipsis:
+        return "Ellipsis"
+    case FatArrow:
+        return "FatArrow"
     case Comment:
         return "Comment"
     case Int:
@@ -0,0 +1,194 @@
+// Package lang embeds the interpreter in Go programs. A VM runs scripts
+// in one global scope, so later scripts and Call see what earlier ones
+// defined, and converts values between Go and the language:
+//
+//	vm := lang.NewVM(lang.Options{})
+//	if err := vm.RunString(`func add(a, b) { return a + b; }`); err != nil {
+//		return err
+//	}
+//	sum, err := vm.Call("add", 1, 2)
+package lang
+
+import (
+	"context"
+	"io"
+	"lang/internal/eval"
+	"lang/internal/lexer"
+	"lang/internal/parser"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// Sandbox restricts what scripts may do, see Options.
+type Sandbox = eval.Sandbox
+
+// ErrCancelled is wrapped by the error of a script or call stopped by
+// its context.
+var ErrCancelled = eval.ErrCancelled
+
+// Options configures a VM.
+type Options struct {
+	// MaxCallDepth limits nested calls, 10000 when 0
+	MaxCallDepth int
+	// Sandbox limits every script and call, nil allows everything
+	Sandbox *Sandbox
+	// Stdin is read by input, os.Stdin when nil
+	Stdin io.Reader
+	// Stdout is written by print and println, os.Stdout when nil
+	Stdout io.Writer
+	// Stderr gets diagnostics, like errors in imported files, os.Stderr
+	// when nil
+	Stderr io.Writer
+}
+
+// VM runs scripts and calls their functions. It is not safe for
+// concurrent use.
+type VM struct {
+	evaluator *eval.Evaluator
+}
+
+// Error lists everything a script or call failed with.
+type Error struct {
+	// Syntax is set if the script couldn't be parsed and didn't run
+	Syntax bool
+	Errors []error
+}
+
+func (e *Error) Error() string {
+	lines := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		lines[i] = err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (e *Error) Unwrap() []error {
+	return e.Errors
+}
+
+// NewVM returns a VM with an empty global scope.
+func NewVM(opts Options) *VM {
+	evaluator := eval.NewEvaluatorAutoEnv(nil)
+	evaluator.MaxCallDepth = opts.MaxCallDepth
+	evaluator.Sandbox = opts.Sandbox
+	evaluator.Stdin = opts.Stdin
+	evaluator.Stdout = opts.Stdout
+	evaluator.Stderr = opts.Stderr
+	vm := &VM{evaluator: evaluator}
+	// unfinished generators would keep their goroutines forever
+	runtime.AddCleanup(vm, (*eval.Evaluator).StopGenerators, evaluator)
+	return vm
+}
+
+// RunString runs the script src.
+func (vm *VM) RunString(src string) error {
+	return vm.RunStringContext(context.Background(), src)
+}
+
+// RunStringContext runs the script src until it ends or ctx is done.
+func (vm *VM) RunStringContext(ctx context.Context, src string) error {
+	program, err := parse(src)
+	if err != nil {
+		return err
+	}
+
+	e := vm.evaluator
+	e.Entry = program
+	e.Errors = nil
+	e.EvalContext(ctx)
+	if len(e.Errors) != 0 {
+		return &Error{Errors: e.Errors}
+	}
+	return nil
+}
+
+// parse lexes and parses src.
+func parse(src string) (*parser.ProgramNode, *Error) {
+	toks, lexErr := lexer.NewLexer().Read(src)
+	if lexErr != nil {
+		return nil, &Error{Syntax: true, Errors: []error{lexErr}}
+	}
+	program, errs := parser.NewParser(toks).Parse()
+	if len(errs) != 0 {
+		return nil, &Error{Syntax: true, Errors: errs}
+	}
+	return program, nil
+}
+
+// RunFile runs the script in the file path.
+func (vm *VM) RunFile(path string) error {
+	return vm.RunFileContext(context.Background(), path)
+}
+
+// RunFileContext runs the script in the file path until it ends or ctx
+// is done.
+func (vm *VM) RunFileContext(ctx context.Context, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return vm.RunStringContext(ctx, string(data))
+}
+
+// Call calls the function name defined by a script with args converted
+// to values of the language, and returns its result converted for Go.
+// See Get for the conversions.
+func (vm *VM) Call(name string, args ...any) (any, error) {
+	return vm.CallContext(context.Background(), name, args...)
+}
+
+// CallContext is Call stopping once ctx is done.
+func (vm *VM) CallContext(ctx context.Context, name string, args ...any) (any, error) {
+	e := vm.evaluator
+	e.Errors = nil
+	value, err := e.CallContext(ctx, name, args...)
+	if len(e.Errors) != 0 {
+		return nil, &Error{Errors: e.Errors}
+	}
+	return value, err
+}
+
+// Get returns the global variable name, ok is false if there is none.
+// nil, bools, numbers and strings become nil, bool, int, float64 and
+// string, arrays become []any, []int or []float64 and instances a
+// map[string]any of their public fields. Values without a Go
+// equivalent, like tasks, are returned as they are and can be passed
+// back to the VM.
+func (vm *VM) Get(name string) (value any, ok bool) {
+	return vm.evaluator.Global(name)
+}
+
+// Set assigns value to the global variable name, declaring it if it
+// doesn't exist. Go bools, numbers, strings and slices of them are
+// converted to values of the language, other values are an error.
+func (vm *VM) Set(name string, value any) error {
+	return vm.evaluator.SetGlobal(name, value)
+}
+
+// RegisterFunc makes the Go function fn callable from scripts as name,
+// like a builtin. Arguments are converted to the parameter types of fn,
+// so func(a int, s string) (int, error) takes an int and a string, and
+// its result is converted back. fn may return nothing, a value, an
+// error or a value and an error, a non nil error fails the script.
+func (vm *VM) RegisterFunc(name string, fn any) error {
+	return vm.evaluator.RegisterFunc(name, fn)
+}
+
+// RegisterModule makes members importable with 'import name;', as
+// name.member, or with 'import name > member;'. Functions are called
+// like those of RegisterFunc, other members are constants.
+func (vm *VM) RegisterModule(name string, members map[string]any) error {
+	return vm.evaluator.RegisterModule(name, members)
+}
+
+// RegisterClass makes the struct type of sample the class name. Its
+// exported fields become fields, named by their `lang:"name"` tag or
+// with a lower case first letter and defaulting to their value in
+// sample, and the exported methods of its pointer become methods named
+// the same way. Instances passed to Go functions and methods are copied
+// into structs, and copied back after the call for pointers. Returned
+// structs become new instances.
+func (vm *VM) RegisterClass(name string, sample any) error {
+	return vm.evaluator.RegisterClass(name, sample)
+}
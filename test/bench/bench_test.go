@@ -0,0 +1,99 @@
+package bench
+
+import (
+	"io"
+	"lang/internal/eval"
+	"lang/internal/lexer"
+	"lang/internal/parser"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Examples that run without reading input.
+var examples = []string{
+	"brainf",
+	"leetcode",
+	"matrix",
+	"palindrome",
+	"stringManipulation",
+	"strings",
+	"types",
+}
+
+const loopSource = `
+func main() {
+    var sum = 0;
+    for (var i = 0; i < 20000; i++) {
+        var sq = i * i;
+        if (mod(sq, 2) == 0) {
+            sum += sq;
+        }
+    }
+    var j = 0;
+    while (j < 20000) {
+        j += 1;
+    }
+    return sum;
+}
+main();
+`
+
+const callSource = `
+func fib(n) {
+    if (n < 2) {
+        return n;
+    }
+    return fib(n - 1) + fib(n - 2);
+}
+fib(18);
+`
+
+func parse(b *testing.B, src string) *parser.ProgramNode {
+	b.Helper()
+	toks, err := lexer.NewLexer().Read(src)
+	if err != nil {
+		b.Fatalf("Lexer error: %v", err)
+	}
+	program, errs := parser.NewParser(toks).Parse()
+	if len(errs) != 0 {
+		b.Fatalf("Parse errors: %v", errs)
+	}
+	return program
+}
+
+// run evaluates program b.N times with stdout discarded.
+func run(b *testing.B, program *parser.ProgramNode) {
+	b.Helper()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		evaluator := eval.NewEvaluatorAutoEnv(program)
+		evaluator.Stdout = io.Discard
+		evaluator.Eval()
+		if len(evaluator.Errors) != 0 {
+			b.Fatalf("Runtime errors: %v", evaluator.Errors)
+		}
+	}
+}
+
+func BenchmarkExamples(b *testing.B) {
+	for _, name := range examples {
+		data, err := os.ReadFile(filepath.Join("..", "..", "examples", name+".lang"))
+		if err != nil {
+			b.Fatal(err)
+		}
+		program := parse(b, string(data))
+		b.Run(name, func(b *testing.B) {
+			run(b, program)
+		})
+	}
+}
+
+func BenchmarkLoops(b *testing.B) {
+	run(b, parse(b, loopSource))
+}
+
+func BenchmarkCalls(b *testing.B) {
+	run(b, parse(b, callSource))
+}
@@ -0,0 +1,257 @@
+package check
+
+import (
+	"errors"
+	"lang/internal/check"
+	"lang/internal/lexer"
+	"lang/internal/parser"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func checkSource(t *testing.T, src string) []error {
+	t.Helper()
+	toks, err := lexer.NewLexer().Read(src)
+	if err != nil {
+		t.Fatalf("Lexer error: %v", err)
+	}
+	program, errs := parser.NewParser(toks).Parse()
+	if len(errs) != 0 {
+		t.Fatalf("Parse errors: %v", errs)
+	}
+	return check.Check(program)
+}
+
+// expectTypeErrors checks that src produces exactly one error per
+// expected substring, in order.
+func expectTypeErrors(t *testing.T, src string, expected ...string) {
+	t.Helper()
+	errs := checkSource(t, src)
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d type errors, got %d: %v", len(expected), len(errs), errs)
+	}
+	for i, want := range expected {
+		if !strings.Contains(errs[i].Error(), want) {
+			t.Errorf("Expected error %d to contain %q, got %q", i, want, errs[i])
+		}
+	}
+}
+
+func TestUnannotatedCodePasses(t *testing.T) {
+	expectTypeErrors(t, `
+func add(a, b) {
+    return a + b;
+}
+
+func main() {
+    var x = 1;
+    x = "now a string";
+    println(add(x, 2));
+}
+main();
+`)
+}
+
+func TestVariableAnnotations(t *testing.T) {
+	expectTypeErrors(t, `
+func main() {
+    var a: int = 1;
+    var b: float = a;
+    var c: string = a;
+    a = 2.5;
+    var d: []int = [1, 2];
+    var e: string = d[0];
+    var f: Missing = nil;
+}
+`,
+		"cannot use int as string in definition of 'c'",
+		"cannot assign float to 'a' of type int",
+		"cannot use int as string in definition of 'e'",
+		"unknown type 'Missing'")
+}
+
+func TestFunctionAnnotations(t *testing.T) {
+	expectTypeErrors(t, `
+func repeat(s: string, n: int): string {
+    var out = "";
+    for (i in 0..n) {
+        out += s;
+    }
+    return out;
+}
+
+func count(): int {
+    return "one";
+}
+
+func main() {
+    var ok: string = repeat("a", 2);
+    var bad: int = repeat("a", 2);
+    repeat(1, 2);
+    repeat("a");
+}
+`,
+		"cannot return string from function returning int",
+		"cannot use string as int in definition of 'bad'",
+		"cannot use int as string in argument 's' of 'repeat'",
+		"'repeat' expects 2 arguments, got 1")
+}
+
+func TestClassAnnotations(t *testing.T) {
+	expectTypeErrors(t, `
+class Point {
+    pub x: int = 0,
+    pub y: float,
+    pub name: string = 1
+}
+
+pub Point->init(x: int, y: float) {
+    self.x = x;
+    self.y = y;
+    self.name = "p";
+}
+
+pub Point->len(): float {
+    return self.x + self.y;
+}
+
+func main() {
+    var p: Point = Point("1", 2);
+    var l: int = p.len();
+    p.x = "s";
+    var q = Point{x: 1, z: 2};
+}
+`,
+		"cannot use int as string in field 'name' of class 'Point'",
+		"cannot use string as int in argument 'x' of 'Point->init'",
+		"cannot use float as int in definition of 'l'",
+		"cannot assign string to 'p.x' of type int",
+		"class 'Point' has no field 'z'")
+}
+
+func TestAssignable(t *testing.T) {
+	cases := []struct {
+		target, source string
+		want           bool
+	}{
+		{"int", "int", true},
+		{"float", "int", true},
+		{"int", "float", false},
+		{"", "string", true},
+		{"string", "", true},
+		{"any", "int", true},
+		{"Point", "nil", true},
+		{"int", "nil", false},
+		{"[]float", "[]int", true},
+		{"[]int", "[]string", false},
+		{"[]", "[]int", true},
+	}
+	for _, c := range cases {
+		if got := check.Assignable(c.target, c.source); got != c.want {
+			t.Errorf("Assignable(%q, %q) = %v, want %v",
+				c.target, c.source, got, c.want)
+		}
+	}
+}
+
+func TestDefaultNamedAndVariadicParams(t *testing.T) {
+	expectTypeErrors(t, `
+func f(a: int, b: string = "x", ...rest: float) {}
+func g(n: int = "s") {}
+
+func main() {
+    f(1, "y", 1.5, 2);
+    f(a: 1);
+    f(b: "z", a: 2);
+    f(b: "z");
+    f(1, c: 2);
+    f(1, "y", "bad");
+    f();
+}
+`,
+		"cannot use string as int in default of 'n'",
+		"missing argument 'a' in call to 'f'",
+		"'f' has no parameter 'c'",
+		"cannot use string as float in argument 'rest' of 'f'",
+		"'f' expects 1 arguments, got 0")
+}
+
+func TestDestructuring(t *testing.T) {
+	expectTypeErrors(t, `
+class Point {
+    pub x: int = 0,
+    pub y: int = 0
+}
+
+func pair(): []int {
+    return 1, 2;
+}
+
+func main() {
+    var (a, b) = pair();
+    var s: string = a;
+    var p: Point = Point{};
+    var {x, z} = p;
+    var n: int = x;
+}
+`,
+		"cannot use int as string in definition of 's'",
+		"class 'Point' has no field 'z'")
+}
+
+func TestTasksAndChannels(t *testing.T) {
+	expectTypeErrors(t, `
+func work(n: int): int {
+    return n;
+}
+
+func main() {
+    var t: task = spawn work(1);
+    var ch: channel = channel();
+    var bad: int = spawn work("one");
+    var c: string = ch;
+}
+`,
+		"cannot use string as int in argument 'n' of 'work'",
+		"cannot use task as int in definition of 'bad'",
+		"cannot use channel as string in definition of 'c'")
+}
+
+func TestMatchPatterns(t *testing.T) {
+	expectTypeErrors(t, `
+func classify(n, limit) {
+    return match (n) {
+        limit => "at limit",
+        int => "int",
+        Circle => "circle",
+        _ => "other"
+    };
+}
+`,
+		"unknown type 'Circle'")
+}
+
+func TestCheckTruncatedScripts(t *testing.T) {
+	bin := filepath.Join(t.TempDir(), "lang")
+	if out, err := exec.Command("go", "build", "-o", bin, "lang/cmd/lang").CombinedOutput(); err != nil {
+		t.Fatalf("Building lang: %v\n%s", err, out)
+	}
+	for _, src := range []string{"var x = ", "if (", "outer:", "spawn", "func f(a, "} {
+		path := filepath.Join(t.TempDir(), "main.lang")
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		var stderr strings.Builder
+		cmd := exec.Command(bin, "check", path)
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+		var exit *exec.ExitError
+		if !errors.As(err, &exit) || exit.ExitCode() != 1 ||
+			!strings.Contains(stderr.String(), "Parse error") {
+			t.Errorf("%q: Expected parse errors, got %v: %s", src, err, stderr.String())
+		}
+	}
+}
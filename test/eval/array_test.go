@@ -0,0 +1,47 @@
+package eval
+
+import "testing"
+
+func TestArrayConstructor(t *testing.T) {
+	expectOutput(t, `
+func main() {
+    var a = array(3, 0);
+    a[1] = 5;
+    a[3] = 7;
+    println(a, type(a), len(a));
+}
+main();
+`, "[0, 5, 0, 7][]int4\n")
+}
+
+func TestFloatArrayPromotesInts(t *testing.T) {
+	expectOutput(t, `
+func main() {
+    var a = array(2, 0.5);
+    a[0] = 2;
+    println(a, type(a));
+}
+main();
+`, "[2, 0.5][]float\n")
+}
+
+func TestTypedArrayRejectsOtherTypes(t *testing.T) {
+	expectError(t, `
+func main() {
+    var a = array(2, 0);
+    a[0] = 1.5;
+}
+main();
+`)
+}
+
+func TestMatrix(t *testing.T) {
+	expectOutput(t, `
+func main() {
+    var m = matrix(2, 2, 0);
+    m[0][1] = 3;
+    println(m, m[0][1], m[1][1]);
+}
+main();
+`, "[[0, 3], [0, 0]]30\n")
+}
@@ -4,20 +4,13 @@ import (
 	"bytes"
 	"lang/internal/eval"
 	"lang/internal/parser"
-	"os"
+	"strings"
 	"testing"
 )
 
 func TestBuiltinPrint(t *testing.T) {
 	var buf bytes.Buffer
 
-	// Save original stdout
-	old := os.Stdout
-
-	// Create a pipe
-	r, w, _ := os.Pipe()
-	os.Stdout = w
-
 	input := parser.ProgramNode{
 		Nodes: []parser.Node{
 			&parser.FunctionCallNode{
@@ -31,14 +24,12 @@ func TestBuiltinPrint(t *testing.T) {
 	expectErrs := 0
 	expectOutput := "this test"
 	evaluator := eval.NewEvaluatorAutoEnv(&input)
+	evaluator.Stdout = &buf
 	evaluator.Eval()
-	w.Close()
 	if len(evaluator.Errors) != expectErrs {
 		t.Errorf("Expected %v errors, got %v", expectErrs, evaluator.Errors)
 	}
 
-	os.Stdout = old
-	buf.ReadFrom(r)
 	output := buf.String()
 
 	if expectOutput != output {
@@ -78,65 +69,33 @@ func TestBuiltinLen(t *testing.T) {
 	// }
 }
 
-func _TestBuiltinInput(t *testing.T) {
-    inputString := "test input\n"
-
-    // Backup original stdin and stdout
-    oldStdin := os.Stdin
-    oldStdout := os.Stdout
-
-    // Setup stdin pipe with inputString
-    rIn, wIn, err := os.Pipe()
-    if err != nil {
-        t.Fatalf("Failed to create stdin pipe: %v", err)
-    }
-    _, err = wIn.Write([]byte(inputString))
-    if err != nil {
-        t.Fatalf("Failed to write to stdin pipe: %v", err)
-    }
-    wIn.Close()
-    os.Stdin = rIn
-
-    // Setup stdout pipe to capture output
-    rOut, wOut, err := os.Pipe()
-    if err != nil {
-        t.Fatalf("Failed to create stdout pipe: %v", err)
-    }
-    os.Stdout = wOut
-
-    // Build AST: println(input())
-    inputCall := &parser.FunctionCallNode{
-        Name: &parser.IdentifierNode{Name: "input"},
-        Args: []parser.Node{},
-    }
-
-    printlnCall := &parser.FunctionCallNode{
-        Name: &parser.IdentifierNode{Name: "println"},
-        Args: []parser.Node{inputCall},
-    }
-
-    program := parser.ProgramNode{
-        Nodes: []parser.Node{printlnCall},
-    }
+func TestBuiltinInput(t *testing.T) {
+	// Build AST: println(input("name? "))
+	inputCall := &parser.FunctionCallNode{
+		Name: &parser.IdentifierNode{Name: "input"},
+		Args: []parser.Node{&parser.LiteralNode{Value: "name? "}},
+	}
 
-    evaluator := eval.NewEvaluatorAutoEnv(&program)
-    evaluator.Eval()
+	printlnCall := &parser.FunctionCallNode{
+		Name: &parser.IdentifierNode{Name: "println"},
+		Args: []parser.Node{inputCall},
+	}
 
-    // Close and restore stdout & stdin
-    wOut.Close()
-    os.Stdout = oldStdout
-    os.Stdin = oldStdin
+	program := parser.ProgramNode{
+		Nodes: []parser.Node{printlnCall, printlnCall},
+	}
 
-    // Read and check captured stdout
-    var outputBuf bytes.Buffer
-    outputBuf.ReadFrom(rOut)
-    output := outputBuf.String()
+	var output bytes.Buffer
+	evaluator := eval.NewEvaluatorAutoEnv(&program)
+	evaluator.Stdin = strings.NewReader("first\nsecond\n")
+	evaluator.Stdout = &output
+	evaluator.Eval()
 
-    expectedOutput := "test input\n"
-    if len(evaluator.Errors) > 0 {
-        t.Fatalf("Unexpected errors: %v", evaluator.Errors)
-    }
-    if output != expectedOutput {
-        t.Errorf("Expected output %q, got %q", expectedOutput, output)
-    }
+	expectedOutput := "name? first\nname? second\n"
+	if len(evaluator.Errors) > 0 {
+		t.Fatalf("Unexpected errors: %v", evaluator.Errors)
+	}
+	if output.String() != expectedOutput {
+		t.Errorf("Expected output %q, got %q", expectedOutput, output.String())
+	}
 }
@@ -0,0 +1,177 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"io"
+	"lang/internal/eval"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// expectCancelled checks that err stopped the program because ctx was
+// done with cause, and that it is the only error.
+func expectCancelled(t *testing.T, e *eval.Evaluator, err, cause error) {
+	t.Helper()
+	if !errors.Is(err, eval.ErrCancelled) || !errors.Is(err, cause) {
+		t.Errorf("Expected a cancellation by %v, got %v", cause, err)
+	}
+	if len(e.Errors) != 1 || e.Errors[0] != err {
+		t.Errorf("Expected only the cancellation error, got %v", e.Errors)
+	}
+}
+
+func TestEvalContextCancelsLoopsAndCalls(t *testing.T) {
+	cases := map[string]string{
+		"loop": `
+func main() {
+    var i = 0;
+    while (true) { i = i + 1; }
+}
+main();
+`,
+		"recursion": `
+func spin(n) {
+    return spin(n + 1);
+}
+spin(0);
+`,
+		"sleep": `
+sleep(60000);
+`,
+		"recv": `
+var ch = channel();
+recv(ch);
+`,
+		"timer": `
+func late() {
+    println("late");
+}
+setTimeout(late, 60000);
+`,
+	}
+	for name, src := range cases {
+		t.Run(name, func(t *testing.T) {
+			e := newEvaluator(t, src)
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			err := e.EvalContext(ctx)
+			if time.Since(start) > 5*time.Second {
+				t.Errorf("Cancellation took %s", time.Since(start))
+			}
+			expectCancelled(t, e, err, context.DeadlineExceeded)
+		})
+	}
+}
+
+func TestEvalContextCancelsInputAndFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	stdin, input := io.Pipe()
+	defer input.Close()
+
+	for _, src := range []string{
+		`input();`,
+		`fetch("` + server.URL + `");`,
+	} {
+		e := newEvaluator(t, src)
+		e.Stdin = stdin
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		start := time.Now()
+		err := e.EvalContext(ctx)
+		cancel()
+		if time.Since(start) > 5*time.Second {
+			t.Errorf("Cancellation took %s", time.Since(start))
+		}
+		expectCancelled(t, e, err, context.DeadlineExceeded)
+	}
+}
+
+func TestInputAfterCancelledInput(t *testing.T) {
+	stdin, input := io.Pipe()
+	defer input.Close()
+	e, _ := runWith(t, `
+func ask() {
+    return input();
+}
+`, func(e *eval.Evaluator) { e.Stdin = stdin })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := e.CallContext(ctx, "ask"); !errors.Is(err, eval.ErrCancelled) {
+		t.Fatalf("Expected a cancellation, got %v", err)
+	}
+	// the line the cancelled input waited for goes to the next one
+	go io.WriteString(input, "hello\n")
+	if value, err := e.Call("ask"); err != nil || value != "hello" {
+		t.Errorf("Expected %q, got %v and %v", "hello", value, err)
+	}
+}
+
+func TestEvalContextCancelledBeforeStart(t *testing.T) {
+	e := newEvaluator(t, `
+println("never");
+`)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := e.EvalContext(ctx)
+	expectCancelled(t, e, err, context.Canceled)
+}
+
+func TestEvalContextFinishes(t *testing.T) {
+	e := newEvaluator(t, `
+var x = 1 + 2;
+`)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := e.EvalContext(ctx); err != nil || len(e.Errors) != 0 {
+		t.Fatalf("Unexpected error %v and errors %v", err, e.Errors)
+	}
+}
+
+func TestCallContext(t *testing.T) {
+	e := newEvaluator(t, `
+func greet(name) {
+    return "hello " + name;
+}
+func add(a, b) {
+    return a + b;
+}
+func forever() {
+    while (true) {}
+}
+`)
+	e.Eval()
+	if len(e.Errors) != 0 {
+		t.Fatalf("Unexpected errors: %v", e.Errors)
+	}
+
+	if value, err := e.Call("greet", "bob"); err != nil || value != "hello bob" {
+		t.Errorf("Expected %q, got %v and %v", "hello bob", value, err)
+	}
+	if value, err := e.Call("add", 2, 3); err != nil || value != 5 {
+		t.Errorf("Expected 5, got %v and %v", value, err)
+	}
+	if _, err := e.Call("add", 1); err == nil {
+		t.Error("Expected an error for a missing argument")
+	}
+	if _, err := e.Call("missing"); err == nil {
+		t.Error("Expected an error for an unknown function")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := e.CallContext(ctx, "forever"); !errors.Is(err, eval.ErrCancelled) {
+		t.Errorf("Expected a cancellation, got %v", err)
+	}
+
+	// the evaluator can still be used after a cancelled call
+	if value, err := e.Call("add", 1, 1); err != nil || value != 2 {
+		t.Errorf("Expected 2, got %v and %v", value, err)
+	}
+}
@@ -0,0 +1,181 @@
+package eval
+
+import "testing"
+
+const pointClass = `
+class Point {
+    pub x,
+    pub y
+}
+
+pub Point->init(x, y) {
+    self.x = x;
+    self.y = y;
+}
+`
+
+func TestConstructorCall(t *testing.T) {
+	expectOutput(t, pointClass+`
+func main() {
+    var p = Point(1, 2);
+    var q = new Point(3, 4);
+    println(p.x, p.y, q.x, q.y);
+}
+main();
+`, "1234\n")
+}
+
+func TestConstructorMustSetAllFields(t *testing.T) {
+	expectError(t, `
+class Pair {
+    pub a,
+    pub b
+}
+
+pub Pair->init(a) {
+    self.a = a;
+}
+
+func main() {
+    var p = Pair(1);
+}
+main();
+`)
+}
+
+func TestStructLiteralMustSetAllFields(t *testing.T) {
+	expectOutput(t, `
+class Pair {
+    pub a,
+    pub b = 2
+}
+
+func main() {
+    var p = Pair{ a: 1 };
+    println(p.a, p.b);
+}
+main();
+`, "12\n")
+	expectError(t, pointClass+`
+func main() {
+    var p = Point{ x: 1 };
+}
+main();
+`)
+}
+
+func TestConstructorWithoutInit(t *testing.T) {
+	expectOutput(t, `
+class Counter {
+    pub n = 0
+}
+
+func main() {
+    var c = new Counter();
+    println(c.n);
+}
+main();
+`, "0\n")
+	expectError(t, `
+class Counter {
+    pub n = 0
+}
+
+func main() {
+    var c = Counter(1);
+}
+main();
+`)
+}
+
+func TestStructLiteralUnknownField(t *testing.T) {
+	expectError(t, pointClass+`
+func main() {
+    var p = Point { x: 1, z: 2 };
+}
+main();
+`)
+}
+
+const counterClass = `
+class Counter {
+    pub n,
+    pub static created = 0
+}
+
+pub Counter->init(n) {
+    self.n = n;
+    created += 1;
+}
+
+pub static Counter->zero() {
+    return Counter(0);
+}
+`
+
+func TestStaticMembers(t *testing.T) {
+	expectOutput(t, counterClass+`
+func main() {
+    var a = Counter(5);
+    var b = Counter.zero();
+    Counter.created += 10;
+    println(Counter.created, a.created, b.n);
+}
+main();
+`, "12120\n")
+}
+
+func TestPrivateStaticMembers(t *testing.T) {
+	src := `
+class Ids {
+    pri static last = 0
+}
+
+pub static Ids->next() {
+    Ids.last += 1;
+    return Ids.last;
+}
+`
+	expectOutput(t, src+`
+func main() {
+    Ids.next();
+    println(Ids.next());
+}
+main();
+`, "2\n")
+	expectError(t, src+`
+func main() {
+    println(Ids.last);
+}
+main();
+`)
+}
+
+func TestStaticCallOnInstanceMethod(t *testing.T) {
+	expectError(t, counterClass+`
+pub Counter->get() {
+    return self.n;
+}
+
+func main() {
+    Counter.get();
+}
+main();
+`)
+}
+
+func TestFieldAssignDoesNotChangeDefaults(t *testing.T) {
+	expectOutput(t, `
+class Box {
+    pub v = 1
+}
+
+func main() {
+    var a = Box {};
+    a.v += 4;
+    var b = Box {};
+    println(a.v, b.v);
+}
+main();
+`, "51\n")
+}
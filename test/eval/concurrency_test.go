@@ -0,0 +1,206 @@
+package eval
+
+import "testing"
+
+func TestSpawnAndJoin(t *testing.T) {
+	expectOutput(t, `
+func square(x) {
+    return x * x;
+}
+
+class Acc {
+    pub total = 0
+}
+
+pub Acc->add(n) {
+    return self.total + n;
+}
+
+func main() {
+    var tasks = [spawn square(1), spawn square(2), spawn square(3)];
+    println(wait(tasks));
+    var a = Acc{ total: 10 };
+    var t = spawn a.add(5);
+    println(type(t), " ", join(t));
+    println(join(spawn len("four")));
+}
+main();
+`, "[1, 4, 9]\ntask 15\n4\n")
+}
+
+func TestChannels(t *testing.T) {
+	expectOutput(t, `
+func produce(ch, n) {
+    for (i in 0..n) {
+        send(ch, i);
+    }
+    close(ch);
+}
+
+func main() {
+    var ch = channel();
+    spawn produce(ch, 4);
+    var sum = 0;
+    for (x in ch) {
+        sum += x;
+    }
+    println(sum);
+
+    var buf = channel(2);
+    send(buf, "a");
+    send(buf, "b");
+    close(buf);
+    println(recv(buf), recv(buf), recv(buf));
+}
+main();
+`, "6\nabnil\n")
+}
+
+func TestWorkersShareEnvironment(t *testing.T) {
+	expectOutput(t, `
+var results = channel(8);
+
+func worker(id, jobs) {
+    for (j in jobs) {
+        send(results, j * 10);
+    }
+}
+
+func main() {
+    var jobs = channel();
+    var workers = [spawn worker(1, jobs), spawn worker(2, jobs)];
+    for (i in 1..5) {
+        send(jobs, i);
+    }
+    close(jobs);
+    wait(workers);
+    close(results);
+    var total = 0;
+    for (r in results) {
+        total += r;
+    }
+    println(total);
+}
+main();
+`, "100\n")
+}
+
+func TestSpawnedErrors(t *testing.T) {
+	// errors in a task surface where it is joined
+	expectError(t, `
+func fail() {
+    return missing;
+}
+func main() {
+    var t = spawn fail();
+    join(t);
+}
+main();
+`)
+	// and when the program ends if nothing waited for it
+	expectError(t, `
+func fail() {
+    return missing;
+}
+spawn fail();
+`)
+	expectError(t, `
+func main() {
+    var ch = channel(1);
+    close(ch);
+    send(ch, 1);
+}
+main();
+`)
+	expectError(t, `join(1);`)
+	expectParseError(t, `spawn 1 + 2;`)
+}
+
+func TestSpawnEvaluatesArgumentsFirst(t *testing.T) {
+	expectOutput(t, `
+func main() {
+    var ch = channel(3);
+    for (i in 0..3) {
+        spawn send(ch, i * 2);
+    }
+    wait();
+    close(ch);
+    var sum = 0;
+    for (x in ch) {
+        sum += x;
+    }
+    println(sum);
+}
+main();
+`, "6\n")
+}
+
+func TestTasksShareGenerator(t *testing.T) {
+	expectOutput(t, `
+func numbers(n) {
+    for (i in 0..n) {
+        var doubled = i * 2;
+        yield doubled;
+    }
+}
+
+func consume(g) {
+    var sum = 0;
+    for (x in g) {
+        sum += x;
+    }
+    return sum;
+}
+
+func main() {
+    var g = numbers(100);
+    var local = 1;
+    var t1 = spawn consume(g);
+    var t2 = spawn consume(g);
+    for (i in 0..1000) {
+        local = local + i;
+    }
+    println(join(t1) + join(t2), " ", local);
+}
+main();
+`, "9900 499501\n")
+}
+
+func TestTasksShareArray(t *testing.T) {
+	expectOutput(t, `
+func fill(xs, from, to) {
+    for (i in from..to) {
+        xs[i] = i;
+    }
+}
+
+func sum(xs) {
+    var total = 0;
+    for (x in xs) {
+        total += x;
+    }
+    return total;
+}
+
+func main() {
+    var xs = array(100, 0);
+    var ys = [nil, nil, nil, nil];
+    wait(spawn fill(xs, 0, 50), spawn fill(xs, 50, 100), spawn sum(xs));
+    wait(spawn fill(ys, 0, 2), spawn fill(ys, 2, 4));
+    println(sum(xs), " ", ys);
+}
+main();
+`, "4950 [0, 1, 2, 3]\n")
+}
+
+func TestSharingIsPerProgram(t *testing.T) {
+	quiet := newEvaluator(t, `var x = 1;`)
+	busy, _ := runSource(t, `join(spawn len("abc"));`)
+	if !busy.Environment.Shared() {
+		t.Error("Expected the envs of a program that spawned to be shared")
+	}
+	quiet.Eval()
+	if quiet.Environment.Shared() {
+		t.Error("Expected another program's envs not to be shared")
+	}
+}
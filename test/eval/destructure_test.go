@@ -0,0 +1,92 @@
+package eval
+
+import "testing"
+
+func TestMultipleReturnValues(t *testing.T) {
+	expectOutput(t, `
+func divmod(a, b) {
+    var q = 0;
+    while (a >= b) {
+        a -= b;
+        q += 1;
+    }
+    return q, a;
+}
+
+func main() {
+    var (q, r) = divmod(7, 2);
+    println(q, " ", r);
+    println(divmod(9, 4));
+}
+main();
+`, "3 1\n[2, 1]\n")
+}
+
+func TestArrayDestructuring(t *testing.T) {
+	expectOutput(t, `
+func main() {
+    var [first, _, ...rest] = [1, 2, 3, 4];
+    println(first, " ", rest);
+    var [h, ...t] = ["only"];
+    println(h, " ", t);
+    for ((a, b) in [[1, 2], [3, 4]]) {
+        print(a + b, " ");
+    }
+    println("");
+}
+main();
+`, "1 [3, 4]\nonly []\n3 7 \n")
+}
+
+func TestInstanceDestructuring(t *testing.T) {
+	expectOutput(t, `
+class Point {
+    pub x = 0,
+    pub y = 0,
+    pri secret = 1
+}
+
+pub Point->parts() {
+    var {x: px, secret: s} = self;
+    return px, s;
+}
+
+func main() {
+    var p = Point{x: 3, y: 4};
+    var {x, y: py} = p;
+    println(x, " ", py);
+    println(p.parts());
+    var pts = [Point{x: 1, y: 2}, Point{x: 5, y: 6}];
+    for ({x: a, y: b} in pts) {
+        print(a * b, " ");
+    }
+    println("");
+}
+main();
+`, "3 4\n[3, 1]\n2 30 \n")
+}
+
+func TestDestructuringErrors(t *testing.T) {
+	cases := map[string]string{
+		"not enough": `func main() { var (a, b) = [1]; } main();`,
+		"too many":   `func main() { var (a) = [1, 2]; } main();`,
+		"not array":  `func main() { var (a, b) = 5; } main();`,
+		"redefined":  `func main() { var a = 1; var (a, b) = [1, 2]; } main();`,
+		"private": `
+class P { pri s = 1 }
+func main() { var p = P{}; var {s} = p; }
+main();`,
+		"no field": `
+class P { pub s = 1 }
+func main() { var p = P{}; var {z} = p; }
+main();`,
+		"loop item": `func main() { for ((a, b) in [1, 2]) {} } main();`,
+	}
+	for name, src := range cases {
+		t.Run(name, func(t *testing.T) {
+			expectError(t, src)
+		})
+	}
+	expectParseError(t, `var (a, ...b, c) = [1];`)
+	expectParseError(t, `var {...a} = 1;`)
+}
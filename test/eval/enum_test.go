@@ -0,0 +1,63 @@
+package eval
+
+import "testing"
+
+const colorEnum = `
+enum Color { Red, Green, Blue }
+`
+
+func TestEnumMembers(t *testing.T) {
+	expectOutput(t, colorEnum+`
+func main() {
+    var c = Color.Green;
+    println(c, type(c), c.name(), c.ordinal(), int(c));
+    println(c == Color.Green, c == Color.Red, c != Color.Blue);
+}
+main();
+`, "GreenColorGreen11\ntruefalsetrue\n")
+}
+
+func TestEnumValuesAndConversions(t *testing.T) {
+	expectOutput(t, colorEnum+`
+func main() {
+    var vs = Color.values();
+    for (var i = 0; i < len(vs); i++) {
+        println(vs[i].ordinal(), vs[i]);
+    }
+    println(Color.fromString("Blue") == Color.Blue, Color.fromInt(0));
+    println(string(Color.Red) + "!");
+}
+main();
+`, "0Red\n1Green\n2Blue\ntrueRed\nRed!\n")
+}
+
+func TestEnumConversionErrors(t *testing.T) {
+	expectError(t, colorEnum+`
+func main() {
+    Color.fromString("Purple");
+}
+main();
+`)
+	expectError(t, colorEnum+`
+func main() {
+    Color.fromInt(3);
+}
+main();
+`)
+}
+
+func TestEnumMembersAreReadOnly(t *testing.T) {
+	expectError(t, colorEnum+`
+func main() {
+    Color.Red = 1;
+}
+main();
+`)
+	expectError(t, colorEnum+`
+func main() {
+    var c = Color.Red;
+    c.name = "Pink";
+}
+main();
+`)
+}
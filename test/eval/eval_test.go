@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"lang/internal/eval"
 	"lang/internal/parser"
-	"os"
 	"testing"
 )
 
@@ -62,16 +61,9 @@ func TestVarDeclAndUse(t *testing.T) {
     }
 
     var buf bytes.Buffer
-    oldStdout := os.Stdout
-    r, w, _ := os.Pipe()
-    os.Stdout = w
-
     evaluator := eval.NewEvaluatorAutoEnv(&program)
+    evaluator.Stdout = &buf
     evaluator.Eval()
-
-    w.Close()
-    os.Stdout = oldStdout
-    buf.ReadFrom(r)
     output := buf.String()
 
     expectedOutput := "42"
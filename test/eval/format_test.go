@@ -0,0 +1,94 @@
+package eval
+
+import "testing"
+
+const secretPointClass = `
+class Point {
+    pub x,
+    pub y,
+    pri secret
+}
+
+pub Point->init(x, y) {
+    self.x = x;
+    self.y = y;
+    self.secret = 0;
+}
+`
+
+func TestPrintNestedArrays(t *testing.T) {
+	expectOutput(t, `
+func main() {
+    println([1, 2.5, "s", [true, nil]]);
+}
+main();
+`, "[1, 2.5, \"s\", [true, nil]]\n")
+}
+
+func TestPrintInstanceSkipsPrivateFields(t *testing.T) {
+	expectOutput(t, secretPointClass+`
+func main() {
+    var p = Point(1, 2);
+    println(p);
+    println([p, "a"]);
+    println(string(p) + "!");
+}
+main();
+`, "Point{x: 1, y: 2}\n[Point{x: 1, y: 2}, \"a\"]\nPoint{x: 1, y: 2}!\n")
+}
+
+func TestPrintQuotedStrings(t *testing.T) {
+	expectOutput(t, `
+class Note {
+    pub text
+}
+
+func main() {
+    var n = Note{ text: "say \"hi\" \\ bye" };
+    println([1, "x", "a\\b"]);
+    println(string([1, "x"]));
+    println(n);
+    printf([n]);
+    println("tab\tend ", "\\n");
+}
+main();
+`, `[1, "x", "a\\b"]
+[1, "x"]
+Note{text: "say \"hi\" \\ bye"}
+[Note{text: "say \"hi\" \\ bye"}]
+tab	end \n
+`)
+}
+
+func TestPrintUsesToString(t *testing.T) {
+	expectOutput(t, `
+class Money {
+    pub cents
+}
+
+pub Money->init(c) {
+    self.cents = c;
+}
+
+pub Money->toString() {
+    return "$" + string(self.cents);
+}
+
+func main() {
+    println([Money(5)]);
+}
+main();
+`, "[$5]\n")
+}
+
+func TestStructuralEquality(t *testing.T) {
+	expectOutput(t, secretPointClass+`
+func main() {
+    var a = [1, 2];
+    println(a == [1, 2], a != [1, 2], a == [1, 2, 3]);
+    println(Point(1, 2) == Point(1, 2), Point(1, 2) == Point(2, 1));
+    println(1 == 1.0, [1] == [1.0], "a" == "a");
+}
+main();
+`, "truefalsefalse\ntruefalse\ntruetruetrue\n")
+}
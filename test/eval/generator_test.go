@@ -0,0 +1,140 @@
+package eval
+
+import "testing"
+
+func TestGeneratorsInLoops(t *testing.T) {
+	expectOutput(t, `
+func count(from, to) {
+    for (var i = from; i < to; i++) {
+        yield i;
+    }
+}
+
+func naturals() {
+    var n = 0;
+    while (true) {
+        yield n;
+        n += 1;
+    }
+}
+
+func main() {
+    for (i in count(0, 3)) {
+        print(i, " ");
+    }
+    println("");
+    for (n in naturals()) {
+        if (n > 2) {
+            break;
+        }
+        print(n);
+    }
+    println("");
+}
+main();
+`, "0 1 2 \n012\n")
+}
+
+func TestChainedGenerators(t *testing.T) {
+	expectOutput(t, `
+func naturals() {
+    var n = 0;
+    while (true) {
+        yield n;
+        n += 1;
+    }
+}
+
+func evens(src) {
+    for (x in src) {
+        if (mod(x, 2) == 0) {
+            yield x;
+        }
+    }
+}
+
+func take(src, n) {
+    for (x in src) {
+        if (n == 0) {
+            return;
+        }
+        yield x;
+        n -= 1;
+    }
+}
+
+func main() {
+    println(array(take(evens(naturals()), 4)));
+}
+main();
+`, "[0, 2, 4, 6]\n")
+}
+
+func TestGeneratorNextAndMethods(t *testing.T) {
+	expectOutput(t, `
+class Bag {
+    pub items = []
+}
+
+pub Bag->each() {
+    for (x in self.items) {
+        yield x * 10;
+    }
+}
+
+func pair() {
+    yield 5;
+    yield;
+}
+
+func main() {
+    var g = pair();
+    println(g, " ", type(g));
+    println(g.next(), " ", g.next(), " ", g.next(), " ", g.next(-1));
+    var b = Bag{items: [1, 2]};
+    println(array(b.each()));
+    println(array(0..3), " ", array("ab"));
+}
+main();
+`, "<generator pair> generator\n5 nil nil -1\n[10, 20]\n[0, 1, 2] [\"a\", \"b\"]\n")
+}
+
+func TestGeneratorsRunLazily(t *testing.T) {
+	expectOutput(t, `
+func noisy() {
+    println("started");
+    yield 1;
+    println("resumed");
+    yield 2;
+}
+
+func main() {
+    var g = noisy();
+    println("created");
+    println(g.next());
+    println(g.next());
+}
+main();
+`, "created\nstarted\n1\nresumed\n2\n")
+}
+
+func TestGeneratorErrors(t *testing.T) {
+	expectParseError(t, `yield 1;`)
+	expectError(t, `
+func broken() {
+    yield 1;
+    yield missing;
+}
+func main() {
+    for (x in broken()) {
+        println(x);
+    }
+}
+main();
+`)
+	expectError(t, `
+func g() { yield 1; }
+func main() { var x = g(); x.foo(); }
+main();
+`)
+}
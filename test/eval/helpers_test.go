@@ -0,0 +1,91 @@
+package eval
+
+import (
+	"bytes"
+	"lang/internal/eval"
+	"lang/internal/lexer"
+	"lang/internal/parser"
+	"strings"
+	"testing"
+)
+
+// runSource lexes, parses and evaluates src and returns the evaluator
+// together with everything that was printed to stdout.
+func runSource(t *testing.T, src string) (*eval.Evaluator, string) {
+	t.Helper()
+	return runWith(t, src, nil)
+}
+
+// runWith is runSource with setup called on the evaluator before it
+// runs.
+func runWith(t *testing.T, src string, setup func(*eval.Evaluator)) (*eval.Evaluator, string) {
+	t.Helper()
+	evaluator := newEvaluator(t, src)
+	var out bytes.Buffer
+	evaluator.Stdout = &out
+	if setup != nil {
+		setup(evaluator)
+	}
+	evaluator.Eval()
+	return evaluator, out.String()
+}
+
+// newEvaluator lexes and parses src into an evaluator that hasn't run.
+func newEvaluator(t *testing.T, src string) *eval.Evaluator {
+	t.Helper()
+	toks, err := lexer.NewLexer().Read(src)
+	if err != nil {
+		t.Fatalf("Lexer error: %v", err)
+	}
+	program, errs := parser.NewParser(toks).Parse()
+	if len(errs) != 0 {
+		t.Fatalf("Parse errors: %v", errs)
+	}
+	return eval.NewEvaluatorAutoEnv(program)
+}
+
+func expectOutput(t *testing.T, src, expected string) {
+	t.Helper()
+	evaluator, output := runSource(t, src)
+	if len(evaluator.Errors) != 0 {
+		t.Fatalf("Unexpected errors: %v", evaluator.Errors)
+	}
+	if output != expected {
+		t.Errorf("Expected output %q, got %q", expected, output)
+	}
+}
+
+func expectError(t *testing.T, src string) {
+	t.Helper()
+	evaluator, _ := runSource(t, src)
+	if len(evaluator.Errors) == 0 {
+		t.Errorf("Expected runtime error, got none")
+	}
+}
+
+func expectParseError(t *testing.T, src string) {
+	t.Helper()
+	toks, err := lexer.NewLexer().Read(src)
+	if err != nil {
+		t.Fatalf("Lexer error: %v", err)
+	}
+	if _, errs := parser.NewParser(toks).Parse(); len(errs) == 0 {
+		t.Errorf("Expected parse error, got none")
+	}
+}
+
+// expectScopeError expects src to be rejected before running, with an
+// error containing msg.
+func expectScopeError(t *testing.T, src, msg string) {
+	t.Helper()
+	evaluator, output := runSource(t, src)
+	if output != "" {
+		t.Errorf("Expected nothing to run, got output %q", output)
+	}
+	for _, err := range evaluator.Errors {
+		if strings.Contains(err.Error(), msg) {
+			return
+		}
+	}
+	t.Errorf("Expected error containing %q, got %v", msg, evaluator.Errors)
+}
@@ -0,0 +1,121 @@
+package eval
+
+import "testing"
+
+func TestBreakAndContinue(t *testing.T) {
+	expectOutput(t, `
+func main() {
+    for (var i = 0; i < 6; i++) {
+        if (i == 1) {
+            continue;
+        }
+        if (i == 4) {
+            break;
+        }
+        print(i);
+    }
+    var n = 0;
+    while (n < 5) {
+        n += 1;
+        match (n) {
+            2, 4 => { continue; }
+            _ => print(n)
+        }
+    }
+    println("");
+}
+main();
+`, "023135\n")
+}
+
+func TestLabeledBreakAndContinue(t *testing.T) {
+	expectOutput(t, `
+func main() {
+    outer: for (var a = 0; a < 3; a++) {
+        var b = 0;
+        while (b < 3) {
+            b += 1;
+            if (b == 2) {
+                continue outer;
+            }
+            if (a == 2) {
+                break outer;
+            }
+            print(a, b, " ");
+        }
+    }
+    println("done");
+}
+main();
+`, "01 11 done\n")
+}
+
+func TestBlockRunsEveryStatement(t *testing.T) {
+	expectOutput(t, `
+func main() {
+    if (1 == 1) {
+        print("a");
+        while (false) {
+        }
+        print("b");
+    }
+    println("c");
+}
+main();
+`, "abc\n")
+}
+
+// statements with a value, like assignments or calls, don't end a block
+func TestBlockContinuesAfterValues(t *testing.T) {
+	expectOutput(t, `
+func main() {
+    var x = 0;
+    if (x == 0) {
+        x += 1;
+        len("abc");
+        println(x);
+    }
+    var i = 0;
+    while (i < 3) {
+        i += 1;
+        print(i);
+    }
+    println();
+}
+main();
+`, "1\n123\n")
+}
+
+func TestBareReturn(t *testing.T) {
+	expectOutput(t, `
+func check(n) {
+    if (n < 0) {
+        return;
+    }
+    println(n);
+}
+
+func main() {
+    check(-1);
+    check(3);
+}
+main();
+`, "3\n")
+}
+
+func TestLoopJumpOutsideLoop(t *testing.T) {
+	expectError(t, `
+func main() {
+    break;
+}
+main();
+`)
+	expectError(t, `
+func main() {
+    for (var i = 0; i < 2; i++) {
+        continue missing;
+    }
+}
+main();
+`)
+}
@@ -0,0 +1,126 @@
+package eval
+
+import "testing"
+
+const describeFunc = `
+class Point {
+    pub x,
+    pub y
+}
+
+enum Color { Red, Green, Blue }
+
+func describe(v) {
+    return match (v) {
+        1, 2 => "small",
+        "x" => "letter",
+        Point{x: 0} => "on y axis",
+        Color.Red => "red",
+        3..10 => "medium",
+        int => "big",
+        float => "float",
+        Point => "point",
+        _ => "other"
+    };
+}
+`
+
+func TestMatchPatterns(t *testing.T) {
+	expectOutput(t, describeFunc+`
+func main() {
+    var p = Point{x: 0, y: 3};
+    var q = Point{x: 1, y: 1};
+    println(describe(2), describe("x"), describe(3), describe(10));
+    println(describe(2.5), describe(p), describe(q));
+    println(describe(Color.Red), describe(Color.Blue), describe(nil));
+}
+main();
+`, "smalllettermediumbig\nfloaton y axispoint\nredotherother\n")
+}
+
+func TestMatchStatementWithBlocks(t *testing.T) {
+	expectOutput(t, `
+func sign(n) {
+    match (n) {
+        0 => {
+            return "zero";
+        }
+        int => {
+            if (n < 0) {
+                return "negative";
+            }
+            return "positive";
+        }
+    }
+    return "not a number";
+}
+
+func main() {
+    println(sign(0), sign(-4), sign(7), sign("s"));
+}
+main();
+`, "zeronegativepositivenot a number\n")
+}
+
+func TestMatchEvaluatesSubjectOnce(t *testing.T) {
+	expectOutput(t, `
+var calls = 0;
+
+func next() {
+    calls += 1;
+    return calls;
+}
+
+func main() {
+    match (next()) {
+        2 => println("two"),
+        1 => println("one")
+    }
+    println(calls);
+}
+main();
+`, "one\n1\n")
+}
+
+func TestMatchUnreachableArms(t *testing.T) {
+	expectParseError(t, `
+var r = match (1) {
+    _ => 0,
+    1 => 1
+};
+`)
+	expectParseError(t, `
+var r = match (1) {
+    1, 2 => 0,
+    2 => 1
+};
+`)
+}
+
+func TestMatchUnknownType(t *testing.T) {
+	expectError(t, `
+func main() {
+    match (1) {
+        Circle => println("circle")
+    }
+}
+main();
+`)
+}
+
+func TestMatchVariable(t *testing.T) {
+	expectOutput(t, `
+func classify(n, limit) {
+    return match (n) {
+        limit => "at limit",
+        int => "below",
+        _ => "other"
+    };
+}
+
+func main() {
+    println(classify(10, 10), " ", classify(3, 10), " ", classify("x", 10));
+}
+main();
+`, "at limit below other\n")
+}
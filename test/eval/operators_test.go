@@ -0,0 +1,160 @@
+package eval
+
+import "testing"
+
+const vecClass = `
+class Vec {
+    pub x,
+    pub y
+}
+
+pub Vec->init(x, y) {
+    self.x = x;
+    self.y = y;
+}
+
+pub Vec->add(o) {
+    return Vec(self.x + o.x, self.y + o.y);
+}
+
+pub Vec->mul(k) {
+    return Vec(self.x * k, self.y * k);
+}
+
+pub Vec->eq(o) {
+    return self.x == o.x && self.y == o.y;
+}
+
+pub Vec->lt(o) {
+    return self.x < o.x;
+}
+
+pub Vec->neg() {
+    return Vec(0 - self.x, 0 - self.y);
+}
+
+pub Vec->index(i) {
+    if (i == 0) {
+        return self.x;
+    }
+    return self.y;
+}
+
+pub Vec->setIndex(i, v) {
+    if (i == 0) {
+        self.x = v;
+        return;
+    }
+    self.y = v;
+}
+
+pub Vec->toString() {
+    return "(" + string(self.x) + ", " + string(self.y) + ")";
+}
+`
+
+func TestArithmeticOverload(t *testing.T) {
+	expectOutput(t, vecClass+`
+func main() {
+    var a = Vec(1, 2);
+    var b = Vec(3, 4);
+    println(a + b, " ", a * 2, " ", -a);
+}
+main();
+`, "(4, 6) (2, 4) (-1, -2)\n")
+}
+
+func TestComparisonOverload(t *testing.T) {
+	expectOutput(t, vecClass+`
+func main() {
+    var a = Vec(1, 2);
+    var b = Vec(3, 4);
+    println(a == Vec(1, 2), a != b, a < b, a > b, a >= b, a <= b, a == nil);
+}
+main();
+`, "truetruetruefalsefalsetruefalse\n")
+}
+
+func TestIndexOverload(t *testing.T) {
+	expectOutput(t, vecClass+`
+func main() {
+    var a = Vec(1, 2);
+    a[1] = 7;
+    println(a[0], a[1], " ", string(a));
+}
+main();
+`, "17 (1, 7)\n")
+}
+
+const boxClass = `
+class Box {
+    pub items
+}
+
+pub Box->index(i) {
+    return self.items[i];
+}
+
+pub Box->setIndex(i, v) {
+    self.items[i] = v;
+}
+
+pri Box->add(o) {
+    return self.items[0] + o.items[0];
+}
+
+pub Box->merge(o) {
+    return self + o;
+}
+`
+
+func TestFailedIndexOverload(t *testing.T) {
+	evaluator, output := runSource(t, boxClass+`
+func main() {
+    var b = Box { items: [1] };
+    var v = b[5] = 2;
+    println(v);
+}
+main();
+`)
+	if len(evaluator.Errors) == 0 || output == "2\n" {
+		t.Errorf("Expected a failed setIndex to fail the assignment, got %q", output)
+	}
+
+	evaluator, output = runSource(t, `
+class Log {}
+
+pub Log->index(i) {
+    println("index ", i);
+    return i;
+}
+
+func main() {
+    var xs = [1];
+    var l = Log {};
+    l[xs[5]];
+}
+main();
+`)
+	if len(evaluator.Errors) == 0 || output != "" {
+		t.Errorf("Expected a failed index not to reach the index method, got %q", output)
+	}
+}
+
+func TestPrivateOperator(t *testing.T) {
+	expectOutput(t, boxClass+`
+func main() {
+    var a = Box { items: [1] };
+    var b = Box { items: [2] };
+    println(a.merge(b));
+}
+main();
+`, "3\n")
+	expectError(t, boxClass+`
+func main() {
+    var a = Box { items: [1] };
+    println(a + a);
+}
+main();
+`)
+}
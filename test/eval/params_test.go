@@ -0,0 +1,104 @@
+package eval
+
+import "testing"
+
+func TestDefaultAndNamedArguments(t *testing.T) {
+	expectOutput(t, `
+func greet(name, greeting = "Hi", punct = "!") {
+    return greeting + ", " + name + punct;
+}
+
+func span(a, b = a * 2) {
+    return b - a;
+}
+
+func main() {
+    println(greet("Ann"));
+    println(greet("Ann", "Hello"));
+    println(greet(name: "Bob", punct: "?"));
+    println(greet("Cy", punct: "."));
+    println(span(3), " ", span(3, b: 10));
+}
+main();
+`, "Hi, Ann!\nHello, Ann!\nHi, Bob?\nHi, Cy.\n3 7\n")
+}
+
+func TestVariadicFunctions(t *testing.T) {
+	expectOutput(t, `
+func sum(...nums) {
+    var total = 0;
+    for (n in nums) {
+        total += n;
+    }
+    return total;
+}
+
+func tag(label, ...parts: int) {
+    println(label, ": ", parts, " ", len(parts));
+}
+
+func main() {
+    println(sum(), " ", sum(1, 2, 3));
+    tag("a");
+    tag("b", 1, 2);
+}
+main();
+`, "0 6\na: [] 0\nb: [1, 2] 2\n")
+}
+
+func TestMethodArguments(t *testing.T) {
+	expectOutput(t, `
+class Box {
+    pub w = 0,
+    pub h = 0
+}
+
+pub Box->init(w, h = 1) {
+    self.w = w;
+    self.h = h;
+}
+
+pub Box->scale(by = 2, ...ignored) {
+    return Box(self.w * by, h: self.h * by);
+}
+
+func main() {
+    var b = Box(4);
+    println(b);
+    println(b.scale());
+    println(b.scale(by: 3));
+    println(b.scale(1, 2, 3));
+    println(Box(h: 5, w: 2));
+}
+main();
+`, "Box{w: 4, h: 1}\nBox{w: 8, h: 2}\nBox{w: 12, h: 3}\nBox{w: 4, h: 1}\nBox{w: 2, h: 5}\n")
+}
+
+func TestArgumentErrors(t *testing.T) {
+	cases := map[string]string{
+		"missing":    `func f(a, b) {} f(1);`,
+		"too many":   `func f(a) {} f(1, 2);`,
+		"unknown":    `func f(a) {} f(b: 1);`,
+		"twice":      `func f(a) {} f(1, a: 2);`,
+		"positional": `func f(a, b) {} f(a: 1, 2);`,
+		"variadic":   `func f(...r) {} f(r: 1);`,
+		"typed rest": `func f(...r: int) {} f(1, "x");`,
+		"builtin":    `println(x: 1);`,
+		"method": `
+class P { pub x = 1 }
+pub P->get(a) { return a; }
+func main() { var p = P{}; p.get(); }
+main();`,
+	}
+	for name, src := range cases {
+		t.Run(name, func(t *testing.T) {
+			expectError(t, src)
+		})
+	}
+}
+
+func TestParameterListErrors(t *testing.T) {
+	expectParseError(t, `func f(...a, b) {}`)
+	expectParseError(t, `func f(a = 1, b) {}`)
+	expectParseError(t, `func f(...a = 1) {}`)
+}
@@ -0,0 +1,99 @@
+package eval
+
+import "testing"
+
+func TestForInRange(t *testing.T) {
+	expectOutput(t, `
+func main() {
+    var n = 3;
+    for (i in 0..n + 1) {
+        print(i);
+    }
+    for (var i in range(10, 0, -4)) {
+        print(" ", i);
+    }
+    println("");
+}
+main();
+`, "0123 10 6 2\n")
+}
+
+func TestRangeValue(t *testing.T) {
+	expectOutput(t, `
+func main() {
+    var r = range(0, 10, 3);
+    println(r, " ", len(r), " ", type(r), " ", len(5..2));
+}
+main();
+`, "range(0, 10, 3) 4 range 0\n")
+}
+
+func TestRangeStepZero(t *testing.T) {
+	expectError(t, `
+func main() {
+    var r = range(0, 10, 0);
+}
+main();
+`)
+}
+
+func TestForInCollections(t *testing.T) {
+	expectOutput(t, `
+enum Dir { Up, Down }
+
+func main() {
+    foreach (x in [1, "a", 2.5]) {
+        print(x, ";");
+    }
+    for (ch in "abc") {
+        print(ch, "-");
+    }
+    for (d in Dir) {
+        print(d.ordinal(), d);
+    }
+    println("");
+}
+main();
+`, "1;a;2.5;a-b-c-0Up1Down\n")
+}
+
+func TestForInBreakContinue(t *testing.T) {
+	expectOutput(t, `
+func main() {
+    outer: for (a in 0..3) {
+        for (b in 0..3) {
+            if (b == 1) {
+                continue outer;
+            }
+            if (a == 2) {
+                break outer;
+            }
+            print(a, b, " ");
+        }
+    }
+    println("");
+}
+main();
+`, "00 10 \n")
+}
+
+func TestDoWhile(t *testing.T) {
+	expectOutput(t, `
+func main() {
+    var n = 10;
+    do {
+        print(n, " ");
+        n += 1;
+    } while (n < 3);
+    do {
+        n -= 1;
+        if (n == 8) {
+            continue;
+        }
+        print(n, " ");
+    } while (n > 6);
+    println("");
+}
+main();
+`, "10 10 9 7 6 \n")
+}
@@ -0,0 +1,134 @@
+package eval
+
+import (
+	"lang/internal/eval"
+	"strings"
+	"testing"
+)
+
+func TestStackOverflow(t *testing.T) {
+	evaluator, _ := runSource(t, `
+func down(n) {
+    if (n == 0) {
+        return 0;
+    }
+    return 1 + down(n - 1);
+}
+func main() {
+    println(down(100000));
+}
+main();
+`)
+	if len(evaluator.Errors) != 1 {
+		t.Fatalf("Expected a single error, got %d: %v",
+			len(evaluator.Errors), evaluator.Errors)
+	}
+	msg := evaluator.Errors[0].Error()
+	for _, want := range []string{
+		"stack overflow: more than 10000 nested calls",
+		"down called at 6, 20",
+		"... 9990 more",
+		"main called at 11, 5",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("Expected %q in %q", want, msg)
+		}
+	}
+}
+
+func TestMaxCallDepth(t *testing.T) {
+	evaluator, _ := runWith(t, `
+func down(n) {
+    if (n == 0) {
+        return 0;
+    }
+    return 1 + down(n - 1);
+}
+down(20);
+down(50);
+`, func(e *eval.Evaluator) {
+		e.MaxCallDepth = 30
+	})
+	if len(evaluator.Errors) != 1 ||
+		!strings.Contains(evaluator.Errors[0].Error(), "more than 30 nested calls") {
+		t.Errorf("Expected a stack overflow at depth 30, got %v", evaluator.Errors)
+	}
+}
+
+func TestTailCalls(t *testing.T) {
+	expectOutput(t, `
+func count(n, acc) {
+    if (n == 0) {
+        return acc;
+    }
+    return count(n - 1, acc + 1);
+}
+
+func isEven(n) {
+    if (n == 0) {
+        return true;
+    }
+    return isOdd(n - 1);
+}
+
+func isOdd(n) {
+    if (n == 0) {
+        return false;
+    }
+    return isEven(n - 1);
+}
+
+class Walker {
+    pub steps = 0
+}
+
+pub Walker->walk(n) {
+    if (n == 0) {
+        return steps;
+    }
+    steps += 1;
+    return self.walk(n - 1);
+}
+
+func main() {
+    println(count(50000, 0));
+    println(isEven(30001));
+    var w = Walker{ steps: 0 };
+    println(w.walk(20000));
+}
+main();
+`, "50000\nfalse\n20000\n")
+}
+
+func TestTailCallsCheckReturnTypes(t *testing.T) {
+	expectError(t, `
+func name() {
+    return 1;
+}
+func label(): string {
+    return name();
+}
+func main() {
+    label();
+}
+main();
+`)
+}
+
+func TestReturnCallInGenerator(t *testing.T) {
+	expectOutput(t, `
+func done() {
+    println("done");
+}
+func gen() {
+    yield 1;
+    return done();
+}
+func main() {
+    for (x in gen()) {
+        println(x);
+    }
+}
+main();
+`, "1\ndone\n")
+}
@@ -0,0 +1,159 @@
+package eval
+
+import "testing"
+
+func TestResolveUndeclaredNames(t *testing.T) {
+	expectScopeError(t, `
+println("start");
+func main() {
+    var total = 1;
+    println(totl);
+}
+main();
+`, "5, 13: Unknown identifier 'totl'")
+
+	expectScopeError(t, `
+println("start");
+func main() {
+    helpr(1);
+}
+main();
+`, "Function 'helpr' not found")
+
+	expectScopeError(t, `
+println("start");
+func main() {
+    count = 2;
+}
+main();
+`, "Variable 'count' does not exist")
+
+	expectScopeError(t, `
+println("start");
+var p = Pointt{ x: 1 };
+`, "Struct type 'Pointt' not found")
+
+	expectScopeError(t, `
+println("start");
+func main() {
+    if (true) {
+        var inner = 1;
+    }
+    println(inner);
+}
+main();
+`, "Unknown identifier 'inner'")
+}
+
+func TestResolveDuplicates(t *testing.T) {
+	expectScopeError(t, `
+println("start");
+func main() {
+    var x = 1;
+    var x = 2;
+}
+`, "5, 9: 'x' is already declared in this scope")
+
+	expectScopeError(t, `
+func f() {}
+func f() {}
+`, "'f' is already declared in this scope")
+
+	expectScopeError(t, `
+func f(a, b, a) {}
+`, "Duplicate parameter 'a'")
+
+	// separate scopes and for loop variables are fine
+	expectOutput(t, `
+func main() {
+    for (var i = 0; i < 1; i++) {
+        var x = 1;
+    }
+    for (var i = 0; i < 1; i++) {
+        var x = 2;
+        println(x);
+    }
+}
+main();
+`, "2\n")
+}
+
+func TestResolveMisplacedStatements(t *testing.T) {
+	expectScopeError(t, `
+println("start");
+return 1;
+`, "'return' outside of a function")
+
+	expectScopeError(t, `
+println("start");
+func main() {
+    break;
+}
+`, "'break' outside of a loop")
+
+	expectScopeError(t, `
+func main() {
+    while (true) {
+        func inner() {
+            continue;
+        }
+    }
+}
+`, "'continue' outside of a loop")
+
+	expectScopeError(t, `
+func main() {
+    outer: for (i in 0..2) {
+        break inner;
+    }
+}
+`, "'break inner': no enclosing loop is labeled 'inner'")
+
+	expectScopeError(t, `
+println("start");
+func main() {
+    println(self);
+}
+`, "'self' outside of a method")
+
+	expectScopeError(t, `
+class Counter {
+    pub n: int = 0
+}
+pub static Counter->make() {
+    return self.n;
+}
+`, "'self' outside of a method")
+}
+
+func TestResolveAllowsLaterAndMemberNames(t *testing.T) {
+	expectOutput(t, `
+class Counter {
+    pub n = 0
+}
+
+pub Counter->inc() {
+    n += 1;
+    return twice(n);
+}
+
+func twice(v) {
+    return v * 2;
+}
+
+func outer() {
+    func inner() {
+        return later + 1;
+    }
+    var later = 41;
+    return inner();
+}
+
+func main() {
+    var c = Counter{ n: 1 };
+    println(c.inc());
+    println(outer());
+}
+main();
+`, "4\n42\n")
+}
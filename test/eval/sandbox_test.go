@@ -0,0 +1,202 @@
+package eval
+
+import (
+	"fmt"
+	"lang/internal/eval"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// runSandboxed runs src in sandbox and returns its output and errors.
+func runSandboxed(t *testing.T, src string, sandbox eval.Sandbox) (string, []error) {
+	t.Helper()
+	evaluator, output := runWith(t, src, func(e *eval.Evaluator) {
+		e.Sandbox = &sandbox
+	})
+	return output, evaluator.Errors
+}
+
+func expectSandboxError(t *testing.T, errs []error, msg string) {
+	t.Helper()
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), msg) {
+		t.Errorf("Expected a single error containing %q, got %v", msg, errs)
+	}
+}
+
+func TestSandboxBuiltinsAndModules(t *testing.T) {
+	output, errs := runSandboxed(t, `
+println("hi");
+len("abc");
+`, eval.Sandbox{Builtins: []string{"println"}})
+	if output != "hi\n" {
+		t.Errorf("Expected output %q, got %q", "hi\n", output)
+	}
+	expectSandboxError(t, errs, "Builtin 'len' is not allowed in the sandbox")
+
+	_, errs = runSandboxed(t, `
+import utils;
+`, eval.Sandbox{})
+	expectSandboxError(t, errs, "Module 'utils' is not allowed in the sandbox")
+}
+
+func TestSandboxFiles(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "in.txt"), []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	output, errs := runSandboxed(t, `
+println(readAll("in.txt"));
+write("out.txt", "written");
+`, eval.Sandbox{FSRoot: root})
+	if len(errs) != 0 || output != "data\n" {
+		t.Fatalf("Unexpected output %q and errors %v", output, errs)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "out.txt")); string(data) != "written" {
+		t.Errorf("Expected out.txt to hold %q, got %q", "written", data)
+	}
+
+	_, errs = runSandboxed(t, `readAll("../in.txt");`, eval.Sandbox{FSRoot: root})
+	expectSandboxError(t, errs, "escapes")
+
+	_, errs = runSandboxed(t, `write("out.txt", "again");`,
+		eval.Sandbox{FSRoot: root, ReadOnly: true})
+	expectSandboxError(t, errs, "read-only")
+
+	_, errs = runSandboxed(t, `readAll("in.txt");`, eval.Sandbox{})
+	expectSandboxError(t, errs, "file access is not allowed")
+}
+
+func TestSandboxHosts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/away" {
+			http.Redirect(w, r, "http://localhost/", http.StatusFound)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	_, errs := runSandboxed(t, fmt.Sprintf(`fetch("%s/");`, server.URL),
+		eval.Sandbox{Hosts: []string{"127.0.0.1"}})
+	if len(errs) != 0 {
+		t.Errorf("Unexpected errors %v", errs)
+	}
+
+	_, errs = runSandboxed(t, fmt.Sprintf(`fetch("%s/");`, server.URL),
+		eval.Sandbox{})
+	expectSandboxError(t, errs, "host '127.0.0.1' is not allowed")
+
+	_, errs = runSandboxed(t, fmt.Sprintf(`fetch("%s/away");`, server.URL),
+		eval.Sandbox{Hosts: []string{"127.0.0.1"}})
+	expectSandboxError(t, errs, "host 'localhost' is not allowed")
+}
+
+func TestSandboxSteps(t *testing.T) {
+	output, errs := runSandboxed(t, `
+func main() {
+    println("start");
+    var i = 0;
+    while (true) {
+        i += 1;
+    }
+}
+main();
+`, eval.Sandbox{MaxSteps: 1000})
+	if output != "start\n" {
+		t.Errorf("Expected output %q, got %q", "start\n", output)
+	}
+	expectSandboxError(t, errs, "step limit of 1000 exceeded")
+}
+
+func TestSandboxMemory(t *testing.T) {
+	_, errs := runSandboxed(t, `
+var big = array(100000000, 0);
+`, eval.Sandbox{MaxMemory: 64 << 20})
+	expectSandboxError(t, errs, "memory limit of 67108864 bytes exceeded")
+}
+
+func TestSandboxMemoryOfStrings(t *testing.T) {
+	for _, src := range []string{
+		`func main() { var s = "ab"; for (i in 0..34) { s = s + s; } } main();`,
+		`func main() { var s = "ab"; for (i in 0..34) { s += s; } } main();`,
+		`func main() { var s = "ab"; for (i in 0..26) { s += s; } var a = array(s); } main();`,
+		`var m = matrix(4611686018427387904, 4);`,
+	} {
+		_, errs := runSandboxed(t, src, eval.Sandbox{MaxMemory: 64 << 20})
+		expectSandboxError(t, errs, "memory limit of 67108864 bytes exceeded")
+	}
+}
+
+func TestSandboxMemoryOfSmallValues(t *testing.T) {
+	_, errs := runSandboxed(t, `
+func main() {
+    var xs = [];
+    var i = 0;
+    while (true) {
+        xs[i] = [i, i];
+        i += 1;
+    }
+}
+main();
+`, eval.Sandbox{MaxMemory: 64 << 20, Timeout: time.Minute})
+	expectSandboxError(t, errs, "memory limit of 67108864 bytes exceeded")
+}
+
+func TestSandboxTasks(t *testing.T) {
+	_, errs := runSandboxed(t, `
+func block(ch) {
+    recv(ch);
+}
+
+func main() {
+    var ch = channel();
+    for (i in 0..3) {
+        spawn block(ch);
+    }
+}
+main();
+`, eval.Sandbox{MaxTasks: 2, Timeout: time.Minute})
+	expectSandboxError(t, errs, "task limit of 2 exceeded")
+
+	output, errs := runSandboxed(t, `
+func id(n) {
+    return n;
+}
+
+func main() {
+    var sum = 0;
+    for (i in 0..5) {
+        sum += join(spawn id(i));
+    }
+    println(sum);
+}
+main();
+`, eval.Sandbox{MaxTasks: 1})
+	if len(errs) != 0 || output != "10\n" {
+		t.Errorf("Expected finished tasks not to count, got %q and %v", output, errs)
+	}
+}
+
+func TestSandboxTimeout(t *testing.T) {
+	for _, src := range []string{
+		`while (true) {}`,
+		`sleep(60000);`,
+		`recv(channel());`,
+		`func main() { var ch = channel(); for (x in ch) {} } main();`,
+		`func tick() {} setInterval(tick, 1);`,
+		`func block(ch) { recv(ch); } func main() { join(spawn block(channel())); } main();`,
+	} {
+		start := time.Now()
+		_, errs := runSandboxed(t, src, eval.Sandbox{Timeout: 50 * time.Millisecond})
+		if time.Since(start) > 5*time.Second {
+			t.Errorf("%s: took %s", src, time.Since(start))
+		}
+		expectSandboxError(t, errs, "timeout: ran for more than 50ms")
+	}
+}
@@ -16,7 +16,7 @@ func varDef(name string, value parser.Node) parser.VarDefNode {
 	return parser.VarDefNode{
 		Name:     name,
 		Value:    value,
-		Position: parser.Position{1, 1},
+		Position: parser.Position{Row: 1, Column: 1},
 	}
 }
 
@@ -37,7 +37,7 @@ func TestCapitalize(t *testing.T) {
 			&parser.StructMethodCall{
 				Caller:     ident("testVar"),
 				MethodName: "capitalize",
-				Position:   parser.Position{1, 1},
+				Position:   parser.Position{Row: 1, Column: 1},
 			},
 		},
 	}
@@ -72,7 +72,7 @@ func TestStringDoesContains(t *testing.T) {
 				Args: []parser.Node{
 					&parser.LiteralNode{Value: "test"},
 				},
-				Position: parser.Position{1, 1},
+				Position: parser.Position{Row: 1, Column: 1},
 			},
 		},
 	}
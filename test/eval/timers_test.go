@@ -0,0 +1,69 @@
+package eval
+
+import "testing"
+
+func TestTimersRunAfterProgram(t *testing.T) {
+	expectOutput(t, `
+var ticks = 0;
+var id = 0;
+
+func later() {
+    println("later");
+}
+
+func soon() {
+    println("soon");
+}
+
+func tick() {
+    ticks += 1;
+    println("tick ", ticks);
+    if (ticks == 3) {
+        clearTimer(id);
+    }
+}
+
+func main() {
+    setTimeout(later, 30);
+    setTimeout(soon, 5);
+    id = setInterval(tick, 1);
+    var never = setTimeout(later, 1);
+    println(clearTimer(never), " ", clearTimer(never));
+    sleep(1);
+    println("main done");
+}
+main();
+`, "true false\nmain done\ntick 1\ntick 2\ntick 3\nsoon\nlater\n")
+}
+
+func TestTimerCallbackScheduling(t *testing.T) {
+	expectOutput(t, `
+var n = 0;
+
+func again() {
+    n += 1;
+    if (n < 3) {
+        setTimeout(again, 0);
+    }
+    println(n);
+}
+
+setTimeout(again, 0);
+`, "1\n2\n3\n")
+}
+
+func TestTimerErrors(t *testing.T) {
+	expectError(t, `setTimeout(1, 10);`)
+	expectError(t, `sleep(-1);`)
+	expectError(t, `
+func f() {}
+setInterval(f, 0);
+`)
+	// errors in callbacks stop the event loop
+	expectError(t, `
+func fail() {
+    return missing;
+}
+setInterval(fail, 1);
+`)
+}
@@ -0,0 +1,68 @@
+package eval
+
+import "testing"
+
+func TestTypedValuesKeepTheirType(t *testing.T) {
+	expectOutput(t, `
+class Point {
+    pub x: float = 0,
+    pub tag: string
+}
+
+func half(n: float): float {
+    return n / 2;
+}
+
+func main() {
+    var f: float = 3;
+    println(f / 2, " ", half(5));
+    var p = Point{tag: "a"};
+    p.x = 1;
+    println(p.x / 2);
+    var xs: []int = [1, 2];
+    xs[2] = 3;
+    println(xs);
+    var u = 1;
+    u = "untyped";
+    println(u);
+    var q: Point = nil;
+    q = p;
+    println(q.tag);
+}
+main();
+`, "1.5 2.5\n0.5\n[1, 2, 3]\nuntyped\na\n")
+}
+
+func TestTypeErrors(t *testing.T) {
+	cases := map[string]string{
+		"definition": `func main() { var a: int = "s"; } main();`,
+		"assignment": `func main() { var a: int = 1; a = 2.5; } main();`,
+		"compound":   `func main() { var a: int = 1; a += 0.5; } main();`,
+		"element":    `func main() { var xs: []int = [1]; xs[0] = "s"; } main();`,
+		"nil":        `func main() { var s: string = nil; } main();`,
+		"argument":   `func f(s: string) { return s; } f(1);`,
+		"return":     `func f(): int { return "x"; } f();`,
+		"default":    `class P { pub x: int = "s" }`,
+		"init":       `class P { pub x: int } func main() { var p = P{x: "s"}; } main();`,
+		"field": `
+class P { pub x: int = 1 }
+func main() { var p = P{}; p.x = "s"; }
+main();`,
+		"method": `
+class P { pub x: int = 1 }
+pub P->set(v: int) { self.x = v; }
+func main() { var p = P{}; p.set(2.5); }
+main();`,
+		"class": `
+class A { pub x = 1 }
+class B { pub x = 1 }
+func f(a: A) { return a; }
+func main() { var b = B{}; f(b); }
+main();`,
+	}
+	for name, src := range cases {
+		t.Run(name, func(t *testing.T) {
+			expectError(t, src)
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package eval
+
+import (
+	"os"
+	"testing"
+)
+
+const accountClass = `
+class Account {
+    pub owner,
+    pri balance = 0
+}
+
+pub Account->deposit(n) {
+    self.balance += n;
+    self.log();
+}
+
+pri Account->log() {
+    println(self.balance);
+}
+`
+
+func TestPrivateMembersThroughSelf(t *testing.T) {
+	expectOutput(t, accountClass+`
+func main() {
+    var a = Account { owner: "me" };
+    a.deposit(10);
+}
+main();
+`, "10\n")
+}
+
+func TestPrivateFieldAccess(t *testing.T) {
+	expectError(t, accountClass+`
+func main() {
+    var a = Account { owner: "me" };
+    println(a.balance);
+}
+main();
+`)
+	expectError(t, accountClass+`
+func main() {
+    var a = Account { owner: "me" };
+    a.balance = 100;
+}
+main();
+`)
+}
+
+func TestPrivateFieldsOnlyThroughSelf(t *testing.T) {
+	expectError(t, accountClass+`
+pub Account->richer(other) {
+    return self.balance > other.balance;
+}
+
+func main() {
+    var a = Account { owner: "a" };
+    var b = Account { owner: "b" };
+    println(a.richer(b));
+}
+main();
+`)
+	expectOutput(t, accountClass+`
+pub Account->total() {
+    var me = self;
+    return me.balance;
+}
+
+func main() {
+    var a = Account { owner: "a" };
+    a.deposit(10);
+    println(a.total());
+}
+main();
+`, "10\n10\n")
+}
+
+func TestSpawnedPrivateMethod(t *testing.T) {
+	expectOutput(t, accountClass+`
+pub Account->report() {
+    join(spawn self.log());
+}
+
+func main() {
+    var a = Account { owner: "a" };
+    a.deposit(10);
+    a.report();
+}
+main();
+`, "10\n10\n")
+}
+
+func TestPrivateFieldThroughParameterNamedSelf(t *testing.T) {
+	expectError(t, accountClass+`
+func peek(self) {
+    return self.balance;
+}
+
+func main() {
+    var a = Account { owner: "me" };
+    println(peek(a));
+}
+main();
+`)
+}
+
+func TestPrivateMethodCall(t *testing.T) {
+	expectError(t, accountClass+`
+func main() {
+    var a = Account { owner: "me" };
+    a.log();
+}
+main();
+`)
+}
+
+func writeModule(t *testing.T) {
+	t.Chdir(t.TempDir())
+	module := `
+pri func helper(x) {
+    return x * 2;
+}
+
+func double(x) {
+    return helper(x);
+}
+`
+	if err := os.WriteFile("mod.lang", []byte(module), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestImportHidesPrivateSymbols(t *testing.T) {
+	writeModule(t)
+	expectOutput(t, `
+import mod;
+import mod > double;
+
+func main() {
+    println(mod.double(2), double(3));
+}
+main();
+`, "46\n")
+	expectError(t, `
+import mod > helper;
+`)
+	expectError(t, `
+import mod;
+
+func main() {
+    mod.helper(1);
+}
+main();
+`)
+}
+
+func TestImportKeepsPublicClassesPublic(t *testing.T) {
+	t.Chdir(t.TempDir())
+	module := `
+pri class Secret {
+    pub v = 1
+}
+
+func make() {
+    return 1;
+}
+
+class Point {
+    pub x = 0
+}
+
+enum Color { Red, Green }
+`
+	if err := os.WriteFile("shapes.lang", []byte(module), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectOutput(t, `
+import shapes;
+
+func main() {
+    var p = Point { x: 2 };
+    println(p.x, Color.Green);
+}
+main();
+`, "2Green\n")
+	expectError(t, `
+import shapes;
+
+func main() {
+    var s = Secret {};
+}
+main();
+`)
+}
@@ -0,0 +1,72 @@
+package lang
+
+import (
+	"lang"
+	"runtime"
+	"testing"
+	"time"
+)
+
+const numbers = `
+func numbers() {
+    var i = 0;
+    while (true) {
+        yield i;
+        i += 1;
+    }
+}
+`
+
+// expectGoroutines waits for unreachable generators to be collected
+// until no more than n goroutines are left.
+func expectGoroutines(t *testing.T, n int) {
+	t.Helper()
+	for range 100 {
+		runtime.GC()
+		if runtime.NumGoroutine() <= n {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("Expected at most %d goroutines, got %d", n, runtime.NumGoroutine())
+}
+
+func TestUnfinishedGeneratorsEnd(t *testing.T) {
+	before := runtime.NumGoroutine()
+	vm := lang.NewVM(lang.Options{})
+	run(t, vm, numbers+`
+func first() {
+    var g = numbers();
+    return g.next();
+}
+`)
+	for range 100 {
+		run(t, vm, `first();`)
+	}
+	expectGoroutines(t, before)
+	runtime.KeepAlive(vm)
+}
+
+func TestGeneratorsEndWithTheirVM(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for range 100 {
+		run(t, lang.NewVM(lang.Options{}), numbers+`
+var g = numbers();
+g.next();
+`)
+	}
+	expectGoroutines(t, before)
+}
+
+func TestGeneratorsOutliveRuns(t *testing.T) {
+	vm := lang.NewVM(lang.Options{})
+	run(t, vm, numbers+`
+var g = numbers();
+g.next();
+`)
+	runtime.GC()
+	run(t, vm, `var second = g.next();`)
+	if v, _ := vm.Get("second"); v != 1 {
+		t.Errorf("Expected 1, got %v", v)
+	}
+}
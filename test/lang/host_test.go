@@ -0,0 +1,259 @@
+package lang
+
+import (
+	"errors"
+	"fmt"
+	"lang"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type point struct {
+	X, Y   int
+	Label  string `lang:"name"`
+	hidden int
+}
+
+func (p *point) Move(dx, dy int) {
+	p.X += dx
+	p.Y += dy
+}
+
+func (p *point) Dist() float64 {
+	return math.Hypot(float64(p.X), float64(p.Y))
+}
+
+func (p point) String() string {
+	return fmt.Sprintf("%s(%d, %d)", p.Label, p.X, p.Y)
+}
+
+func newHostVM(t *testing.T) *lang.VM {
+	t.Helper()
+	vm := lang.NewVM(lang.Options{})
+	if err := vm.RegisterClass("Point", point{Label: "p"}); err != nil {
+		t.Fatal(err)
+	}
+	return vm
+}
+
+func TestRegisterFunc(t *testing.T) {
+	vm := lang.NewVM(lang.Options{})
+	funcs := map[string]any{
+		"repeat": func(s string, n int) (string, error) {
+			if n < 0 {
+				return "", errors.New("negative count")
+			}
+			return strings.Repeat(s, n), nil
+		},
+		"total": func(xs ...float64) float64 {
+			sum := 0.0
+			for _, x := range xs {
+				sum += x
+			}
+			return sum
+		},
+		"words": func(s string) []string { return strings.Fields(s) },
+		"boom":  func() { panic("broken") },
+	}
+	for name, fn := range funcs {
+		if err := vm.RegisterFunc(name, fn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run(t, vm, `
+var r = repeat("ab", 2);
+var sum = total(1, 2.5);
+var w = words("a b  c");
+var spawned = join(spawn repeat("c", 3));
+`)
+	expected := map[string]any{
+		"r":       "abab",
+		"sum":     3.5,
+		"w":       []any{"a", "b", "c"},
+		"spawned": "ccc",
+	}
+	for name, value := range expected {
+		if got, _ := vm.Get(name); !reflect.DeepEqual(got, value) {
+			t.Errorf("Expected %s to be %#v, got %#v", name, value, got)
+		}
+	}
+
+	errs := map[string]string{
+		`repeat("a", -1);`: "repeat: negative count",
+		`repeat(1, 2);`:    "Type error: cannot use int as string in argument 1 of 'repeat'",
+		`repeat("a");`:     "'repeat' expects 2 args, got 1",
+		`boom();`:          "boom: panic: broken",
+	}
+	for src, msg := range errs {
+		if err := vm.RunString(src); err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("Expected %q to fail with %q, got %v", src, msg, err)
+		}
+	}
+
+	if err := vm.RegisterFunc("bad", func() (int, int) { return 0, 0 }); err == nil {
+		t.Error("Expected an error for a function with two results")
+	}
+	if err := vm.RegisterFunc("bad", 42); err == nil {
+		t.Error("Expected an error for a value that isn't a function")
+	}
+}
+
+type pixel struct {
+	Level uint8
+}
+
+func (p *pixel) Bright() bool { return p.Level > 127 }
+
+type counter struct {
+	Count uint64
+}
+
+func (c *counter) Wrap() { c.Count = math.MaxUint64 }
+
+func TestHostIntegersOverflow(t *testing.T) {
+	vm := lang.NewVM(lang.Options{})
+	vm.RegisterFunc("small", func(n int8) int8 { return n })
+	vm.RegisterFunc("sum", func(bs []byte) (n int) {
+		for _, b := range bs {
+			n += int(b)
+		}
+		return n
+	})
+	vm.RegisterFunc("level", func(p pixel) uint8 { return p.Level })
+	vm.RegisterFunc("huge", func() uint64 { return math.MaxUint64 })
+	vm.RegisterFunc("wrap", func(c *counter) { c.Count = math.MaxUint64 })
+	if err := vm.RegisterClass("Pixel", pixel{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := vm.RegisterClass("Counter", counter{}); err != nil {
+		t.Fatal(err)
+	}
+	run(t, vm, `
+var s = small(-128);
+var n = sum([1, 255]);
+var px = Pixel{ level: 200 };
+var l = level(px);
+`)
+	for name, value := range map[string]any{"s": -128, "n": 256, "l": 200} {
+		if got, _ := vm.Get(name); got != value {
+			t.Errorf("Expected %s to be %v, got %v", name, value, got)
+		}
+	}
+
+	errs := map[string]string{
+		`small(300);`:    "Type error: 300 overflows int8 in argument 1 of 'small'",
+		`small(-129);`:   "Type error: -129 overflows int8 in argument 1 of 'small'",
+		`sum([1, 256]);`: "Type error: 256 overflows uint8 at index 1 in argument 1 of 'sum'",
+		`sum([-1]);`:     "Type error: -1 overflows uint8 at index 0 in argument 1 of 'sum'",
+		`var p = Pixel{ level: 300 }; p.bright();`: "Type error: field 'level' of Pixel: 300 overflows uint8 in 'bright'",
+		`var q = Pixel{ level: 256 }; level(q);`:   "Type error: field 'level' of Pixel: 256 overflows uint8 in argument 1 of 'level'",
+		`huge();`:                                  "huge: 18446744073709551615 overflows int",
+		`var c = Counter{ count: 1 }; c.wrap();`:   "wrap: field 'count' of Counter: 18446744073709551615 overflows int",
+		`var d = Counter{ count: 1 }; wrap(d);`:    "wrap: field 'count' of Counter: 18446744073709551615 overflows int",
+	}
+	for src, msg := range errs {
+		if err := vm.RunString(src); err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("Expected %q to fail with %q, got %v", src, msg, err)
+		}
+	}
+}
+
+func TestRegisterModule(t *testing.T) {
+	vm := lang.NewVM(lang.Options{})
+	err := vm.RegisterModule("geo", map[string]any{
+		"pi":     3.5,
+		"double": func(n int) int { return n * 2 },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run(t, vm, `
+import geo;
+import geo > double;
+
+var a = geo.double(2) + double(3);
+var b = geo.pi;
+`)
+	if a, _ := vm.Get("a"); a != 10 {
+		t.Errorf("Expected 10, got %v", a)
+	}
+	if b, _ := vm.Get("b"); b != 3.5 {
+		t.Errorf("Expected 3.5, got %v", b)
+	}
+	if err := vm.RunString(`import geo > missing;`); err == nil {
+		t.Error("Expected an error importing a missing member")
+	}
+
+	calls := 0
+	vm.RegisterModule("log", map[string]any{"record": func(v any) { calls++ }})
+	if err := vm.RunString(`import log > record; var xs = [1]; record(xs[5]);`); err == nil || calls != 0 {
+		t.Errorf("Expected a failed argument to stop the call, got %v and %d calls", err, calls)
+	}
+
+	sandboxed := lang.NewVM(lang.Options{Sandbox: &lang.Sandbox{}})
+	sandboxed.RegisterModule("geo", map[string]any{"pi": 3.5})
+	if err := sandboxed.RunString(`import geo;`); err == nil {
+		t.Error("Expected the sandbox to forbid a module it doesn't list")
+	}
+}
+
+func TestRegisterClass(t *testing.T) {
+	vm := newHostVM(t)
+	run(t, vm, `
+var p = Point{ x: 3 };
+p.move(0, 4);
+var d = p.dist();
+var s = p.string();
+var n = p.name;
+`)
+	expected := map[string]any{
+		"p": map[string]any{"x": 3, "y": 4, "name": "p"},
+		"d": 5.0,
+		"s": "p(3, 4)",
+		"n": "p",
+	}
+	for name, value := range expected {
+		if got, _ := vm.Get(name); !reflect.DeepEqual(got, value) {
+			t.Errorf("Expected %s to be %#v, got %#v", name, value, got)
+		}
+	}
+
+	if err := vm.RunString(`var q = Point{ hidden: 1 };`); err == nil {
+		t.Error("Expected unexported fields to be hidden")
+	}
+	if err := vm.RunString(`p.x = "text";`); err == nil {
+		t.Error("Expected fields to keep the type of their Go field")
+	}
+	if err := vm.RegisterClass("Point", point{}); err == nil {
+		t.Error("Expected an error registering a class twice")
+	}
+	if err := vm.RegisterClass("Number", 1); err == nil {
+		t.Error("Expected an error registering something that isn't a struct")
+	}
+}
+
+func TestClassesCrossTheBoundary(t *testing.T) {
+	vm := newHostVM(t)
+	vm.RegisterFunc("origin", func() *point { return &point{Label: "o"} })
+	vm.RegisterFunc("shift", func(p *point) { p.X += 10 })
+	vm.RegisterFunc("label", func(p point) string { return p.Label })
+	run(t, vm, `
+var o = origin();
+shift(o);
+var l = label(o);
+func area(p) {
+    return p.x * p.y;
+}
+`)
+	if o, _ := vm.Get("o"); !reflect.DeepEqual(o, map[string]any{"x": 10, "y": 0, "name": "o"}) {
+		t.Errorf("Expected the shifted origin, got %#v", o)
+	}
+	if l, _ := vm.Get("l"); l != "o" {
+		t.Errorf("Expected %q, got %v", "o", l)
+	}
+	if area, err := vm.Call("area", point{X: 2, Y: 3}); err != nil || area != 6 {
+		t.Errorf("Expected 6, got %v and %v", area, err)
+	}
+}
@@ -0,0 +1,303 @@
+package lang
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"lang"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func run(t *testing.T, vm *lang.VM, src string) {
+	t.Helper()
+	if err := vm.RunString(src); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestRunStringKeepsGlobals(t *testing.T) {
+	vm := lang.NewVM(lang.Options{})
+	run(t, vm, `
+var count = 1;
+func add(a, b) {
+    return a + b;
+}
+`)
+	run(t, vm, `
+count = add(count, 41);
+`)
+	if value, ok := vm.Get("count"); !ok || value != 42 {
+		t.Errorf("Expected count to be 42, got %v", value)
+	}
+	if _, ok := vm.Get("add"); ok {
+		t.Error("Expected Get to only return variables")
+	}
+	if _, ok := vm.Get("missing"); ok {
+		t.Error("Expected no value for an undeclared variable")
+	}
+}
+
+func TestUnknownCharacter(t *testing.T) {
+	var stdout bytes.Buffer
+	vm := lang.NewVM(lang.Options{Stdout: &stdout})
+	var script *lang.Error
+	err := vm.RunString(`var x = 1 @ 2;`)
+	if !errors.As(err, &script) || !script.Syntax ||
+		!strings.Contains(err.Error(), "unknown character '@'") {
+		t.Errorf("Expected a syntax error, got %v", err)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("Expected no output, got %q", stdout.String())
+	}
+}
+
+func TestStringEscapes(t *testing.T) {
+	var stdout bytes.Buffer
+	vm := lang.NewVM(lang.Options{Stdout: &stdout})
+	run(t, vm, `println("\x41\u00e9\t\\q");`)
+	if got := stdout.String(); got != "Aé\t\\q\n" {
+		t.Errorf("Expected the escapes decoded once, got %q", got)
+	}
+	var script *lang.Error
+	err := vm.RunString(`var s = "\q";`)
+	if !errors.As(err, &script) || !script.Syntax ||
+		!strings.Contains(err.Error(), "invalid escape sequence") {
+		t.Errorf("Expected a syntax error, got %v", err)
+	}
+}
+
+func TestRunFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "script.lang")
+	if err := os.WriteFile(path, []byte(`var name = "file";`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	vm := lang.NewVM(lang.Options{})
+	if err := vm.RunFile(path); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if value, _ := vm.Get("name"); value != "file" {
+		t.Errorf("Expected %q, got %v", "file", value)
+	}
+	if err := vm.RunFile(filepath.Join(t.TempDir(), "missing.lang")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a missing file error, got %v", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	vm := lang.NewVM(lang.Options{})
+
+	var script *lang.Error
+	err := vm.RunString(`var = ;`)
+	if !errors.As(err, &script) || !script.Syntax {
+		t.Errorf("Expected a syntax error, got %v", err)
+	}
+
+	err = vm.RunString(`
+func main() {
+    println(undefined);
+}
+`)
+	if !errors.As(err, &script) || script.Syntax ||
+		!strings.Contains(err.Error(), "Unknown identifier 'undefined'") {
+		t.Errorf("Expected a scope error, got %v", err)
+	}
+
+	// errors of earlier scripts aren't reported again
+	run(t, vm, `var ok = true;`)
+}
+
+func TestCall(t *testing.T) {
+	vm := lang.NewVM(lang.Options{})
+	run(t, vm, `
+func greet(name) {
+    return "hello " + name;
+}
+func sum(xs) {
+    var total = 0;
+    for (x in xs) {
+        total = total + x;
+    }
+    return total;
+}
+func pair(a, b) {
+    return [a, b];
+}
+func fail() {
+    var xs = [1];
+    return xs[5];
+}
+`)
+
+	if value, err := vm.Call("greet", "go"); err != nil || value != "hello go" {
+		t.Errorf("Expected %q, got %v and %v", "hello go", value, err)
+	}
+	if value, err := vm.Call("sum", []int64{1, 2, 3}); err != nil || value != 6 {
+		t.Errorf("Expected 6, got %v and %v", value, err)
+	}
+	value, err := vm.Call("pair", "a", 1.5)
+	if err != nil || !reflect.DeepEqual(value, []any{"a", 1.5}) {
+		t.Errorf("Expected [a 1.5], got %v and %v", value, err)
+	}
+	if _, err := vm.Call("fail"); err == nil {
+		t.Error("Expected an error from a failing call")
+	}
+	if _, err := vm.Call("greet", map[string]int{}); err == nil {
+		t.Error("Expected an error for an argument that can't be converted")
+	}
+}
+
+func TestGetConvertsValues(t *testing.T) {
+	vm := lang.NewVM(lang.Options{})
+	run(t, vm, `
+class Point {
+    pub x: int = 0,
+    pub y: int = 0,
+    pri secret = "hidden"
+}
+enum Color { Red, Green }
+var p = Point{ x: 1, y: 2 };
+var points = [p, nil];
+var color = Color.Green;
+var floats = [1.5, 2.5];
+var r = 0..3;
+`)
+	cases := map[string]any{
+		"p":      map[string]any{"x": 1, "y": 2},
+		"points": []any{map[string]any{"x": 1, "y": 2}, nil},
+		"color":  "Green",
+		"floats": []any{1.5, 2.5},
+		"r":      []int{0, 1, 2},
+	}
+	for name, expected := range cases {
+		if value, _ := vm.Get(name); !reflect.DeepEqual(value, expected) {
+			t.Errorf("Expected %s to be %#v, got %#v", name, expected, value)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	vm := lang.NewVM(lang.Options{})
+	if err := vm.Set("greeting", "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if err := vm.Set("limit", uint8(3)); err != nil {
+		t.Fatal(err)
+	}
+	run(t, vm, `
+var n: int = 1;
+func main() {
+    n = len(greeting) + limit;
+}
+main();
+`)
+	if value, _ := vm.Get("n"); value != 5 {
+		t.Errorf("Expected 5, got %v", value)
+	}
+	if err := vm.Set("n", "not an int"); err == nil {
+		t.Error("Expected a type error when setting a typed variable")
+	}
+	if value, _ := vm.Get("n"); value != 5 {
+		t.Errorf("Expected n to be unchanged, got %v", value)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	vm := lang.NewVM(lang.Options{})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := vm.RunStringContext(ctx, `
+func main() {
+    while (true) {}
+}
+main();
+`)
+	if !errors.Is(err, lang.ErrCancelled) {
+		t.Errorf("Expected a cancellation, got %v", err)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	vm := lang.NewVM(lang.Options{
+		MaxCallDepth: 10,
+		Sandbox:      &lang.Sandbox{Builtins: []string{}},
+	})
+	err := vm.RunString(`
+func down(n) {
+    if (n == 0) { return 0; }
+    return 1 + down(n - 1);
+}
+down(20);
+`)
+	if err == nil || !strings.Contains(err.Error(), "stack overflow") {
+		t.Errorf("Expected a stack overflow, got %v", err)
+	}
+	err = vm.RunString(`println("hi");`)
+	if err == nil || !strings.Contains(err.Error(), "Builtin 'println' is not allowed") {
+		t.Errorf("Expected the sandbox to forbid println, got %v", err)
+	}
+}
+
+func TestStdio(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	vm := lang.NewVM(lang.Options{
+		Stdin:  strings.NewReader("ada\n"),
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+	run(t, vm, `
+var name = input("name? ");
+println("hi ", name);
+func count(n) {
+    for (var i = 0; i < 50; i++) {
+        print(n);
+    }
+}
+wait(spawn count(1), spawn count(2));
+`)
+	out := stdout.String()
+	if !strings.HasPrefix(out, "name? hi ada\n") {
+		t.Errorf("Expected the prompt and greeting, got %q", out)
+	}
+	if strings.Count(out, "1") != 50 || strings.Count(out, "2") != 50 {
+		t.Errorf("Expected the output of both tasks, got %q", out)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "broken.lang"), []byte("var = ;"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	vm.RunString(`import broken;`)
+	if !strings.Contains(stderr.String(), "Parse error") {
+		t.Errorf("Expected the parse errors on stderr, got %q", stderr.String())
+	}
+}
+
+func TestTruncatedScripts(t *testing.T) {
+	for _, src := range []string{
+		"func f(a, ",
+		"func f(a) { if (a",
+		"var x = 1; match (x) { 1 =>",
+		"var x = ",
+		"if (",
+		"outer:",
+		"spawn",
+		"import",
+		"class A { pub",
+	} {
+		err := lang.NewVM(lang.Options{}).RunString(src)
+		var langErr *lang.Error
+		if !errors.As(err, &langErr) || !langErr.Syntax {
+			t.Errorf("%q: Expected a syntax error, got %v", src, err)
+		}
+	}
+}
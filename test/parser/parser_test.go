@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"lang/internal/lexer"
+	"lang/internal/parser"
+	"lang/internal/token"
+	"testing"
+)
+
+// expectTruncatedErrors parses every prefix of the tokens of src that
+// ends early and checks each one is reported as a parse error.
+func expectTruncatedErrors(t *testing.T, src string) {
+	t.Helper()
+	toks, err := lexer.NewLexer().Read(src)
+	if err != nil {
+		t.Fatalf("Lexer error: %v", err)
+	}
+	for n := 1; n < len(toks); n++ {
+		prefix := toks[:n]
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("Parser panicked on %s: %v", lexemes(prefix), r)
+				}
+			}()
+			if _, errs := parser.NewParser(prefix).Parse(); len(errs) == 0 {
+				t.Errorf("Expected a parse error for %s", lexemes(prefix))
+			}
+		}()
+	}
+}
+
+func lexemes(toks []*token.Token) string {
+	s := ""
+	for _, tok := range toks {
+		s += tok.Lexeme + " "
+	}
+	return s
+}
+
+func TestTruncatedMatch(t *testing.T) {
+	expectTruncatedErrors(t, `match (x) { 1, 2 => "low", Point{x: 0} => { println(x); }, _ => "other" }`)
+}
+
+func TestTruncatedEnum(t *testing.T) {
+	expectTruncatedErrors(t, `enum Color { Red, Green, Blue }`)
+}
+
+func TestTruncatedParams(t *testing.T) {
+	expectTruncatedErrors(t, `func f(a: int, b: []int, c = 1, ...rest) { return a; }`)
+}
+
+func TestTruncatedLabeledLoop(t *testing.T) {
+	expectTruncatedErrors(t, `func f() { outer: while (true) { break outer; } }`)
+}
+
+func TestTruncatedSpawn(t *testing.T) {
+	expectTruncatedErrors(t, `func f() { var t = spawn a.add(5, g.next()); }`)
+}